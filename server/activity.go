@@ -14,6 +14,7 @@ type ActivityType string
 var (
 	ADDED_WATCHED               ActivityType = "ADDED_WATCHED"
 	REMOVED_WATCHED             ActivityType = "REMOVED_WATCHED"
+	RESTORED_WATCHED            ActivityType = "RESTORED_WATCHED"
 	RATING_CHANGED              ActivityType = "RATING_CHANGED"
 	STATUS_CHANGED              ActivityType = "STATUS_CHANGED"
 	STATUS_CHANGED_AUTO         ActivityType = "STATUS_CHANGED_AUTO"
@@ -40,6 +41,9 @@ var (
 	EPISODE_REMOVED             ActivityType = "EPISODE_REMOVED"
 	EPISODE_RATING_CHANGED      ActivityType = "EPISODE_RATING_CHANGED"
 	EPISODE_STATUS_CHANGED      ActivityType = "EPISODE_STATUS_CHANGED"
+	VIEW_STARTED                ActivityType = "VIEW_STARTED"
+	VIEW_FINISHED               ActivityType = "VIEW_FINISHED"
+	VIEW_REMOVED                ActivityType = "VIEW_REMOVED"
 )
 
 type Activity struct {
@@ -56,6 +60,8 @@ type Activity struct {
 	Data string `json:"data" gorm:"not null"`
 	// Custom date for the activity, that the user can define.
 	CustomDate *time.Time `json:"customDate,omitempty"`
+	// Name of episode, for episode activity (from our episode cache).
+	EpisodeName string `json:"episodeName,omitempty" gorm:"-"`
 }
 
 type ActivityAddRequest struct {
@@ -76,6 +82,10 @@ func getActivity(db *gorm.DB, userId uint, watchedId uint) ([]Activity, error) {
 		slog.Error("Failed getting activity from database", "error", res.Error.Error())
 		return []Activity{}, errors.New("failed getting activity")
 	}
+	var contentId *int
+	if res := db.Model(&Watched{}).Select("content_id").Where("id = ? AND user_id = ?", watchedId, userId).Scan(&contentId); res.Error == nil && contentId != nil {
+		addActivityEpisodeNames(db, *contentId, *activity)
+	}
 	return *activity, nil
 }
 
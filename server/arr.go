@@ -11,6 +11,10 @@ type ArrSettings struct {
 	Name string `json:"name,omitempty"`
 	Host string `json:"host,omitempty"`
 	Key  string `json:"key,omitempty"`
+	// Secret included in the webhook url for this server.
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+	// Roles used for routing requests when no routing rule matches.
+	Roles []ArrServerRole `json:"roles,omitempty"`
 }
 
 type SonarrSettings struct {
@@ -19,11 +23,12 @@ type SonarrSettings struct {
 	RootFolder      int  `json:"rootFolder,omitempty"`
 	LanguageProfile int  `json:"languageProfile,omitempty"`
 	AutomaticSearch bool `json:"automaticSearch"`
-	// TODO eventually separate profiles and root for anime content (i can see diff language profile being useful)
+	// Separate profiles/root folders (ex, for anime) are set with routing rules.
 }
 
 func (s *SonarrSettings) safe() SonarrSettings {
 	s.Key = ""
+	s.WebhookSecret = ""
 	return *s
 }
 
@@ -36,6 +41,7 @@ type RadarrSettings struct {
 
 func (s *RadarrSettings) safe() RadarrSettings {
 	s.Key = ""
+	s.WebhookSecret = ""
 	return *s
 }
 
@@ -103,6 +109,15 @@ func addSonarr(s SonarrSettings) error {
 			return errors.New("server with that name already exists")
 		}
 	}
+	if err := validateArrServerRoles(arr.SONARR, s.ArrSettings); err != nil {
+		return err
+	}
+	secret, err := generateUrlSafeString(32)
+	if err != nil {
+		slog.Error("addSonarr: Failed to generate webhook secret", "error", err)
+		return errors.New("failed to generate webhook secret")
+	}
+	s.WebhookSecret = secret
 	Config.SONARR = append(Config.SONARR, s)
 	writeConfig()
 	return nil
@@ -110,8 +125,15 @@ func addSonarr(s SonarrSettings) error {
 
 // Edit sonarr server in config
 func editSonarr(s SonarrSettings) error {
+	if err := validateArrServerRoles(arr.SONARR, s.ArrSettings); err != nil {
+		return err
+	}
 	for i, v := range Config.SONARR {
 		if v.Name == s.Name {
+			// Keep existing webhook secret if not provided.
+			if s.WebhookSecret == "" {
+				s.WebhookSecret = v.WebhookSecret
+			}
 			Config.SONARR[i] = s
 			writeConfig()
 			return nil
@@ -124,6 +146,7 @@ func rmSonarr(name string) error {
 	for i, v := range Config.SONARR {
 		if v.Name == name {
 			Config.SONARR = append(Config.SONARR[:i], Config.SONARR[i+1:]...)
+			rmArrRoutingRulesForServer(arr.SONARR, name)
 			writeConfig()
 			return nil
 		}
@@ -158,6 +181,15 @@ func addRadarr(s RadarrSettings) error {
 			return errors.New("server with that name already exists")
 		}
 	}
+	if err := validateArrServerRoles(arr.RADARR, s.ArrSettings); err != nil {
+		return err
+	}
+	secret, err := generateUrlSafeString(32)
+	if err != nil {
+		slog.Error("addRadarr: Failed to generate webhook secret", "error", err)
+		return errors.New("failed to generate webhook secret")
+	}
+	s.WebhookSecret = secret
 	Config.RADARR = append(Config.RADARR, s)
 	writeConfig()
 	return nil
@@ -165,8 +197,15 @@ func addRadarr(s RadarrSettings) error {
 
 // Edit radarr server in config
 func editRadarr(s RadarrSettings) error {
+	if err := validateArrServerRoles(arr.RADARR, s.ArrSettings); err != nil {
+		return err
+	}
 	for i, v := range Config.RADARR {
 		if v.Name == s.Name {
+			// Keep existing webhook secret if not provided.
+			if s.WebhookSecret == "" {
+				s.WebhookSecret = v.WebhookSecret
+			}
 			Config.RADARR[i] = s
 			writeConfig()
 			return nil
@@ -179,6 +218,7 @@ func rmRadarr(name string) error {
 	for i, v := range Config.RADARR {
 		if v.Name == name {
 			Config.RADARR = append(Config.RADARR[:i], Config.RADARR[i+1:]...)
+			rmArrRoutingRulesForServer(arr.RADARR, name)
 			writeConfig()
 			return nil
 		}
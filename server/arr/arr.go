@@ -10,9 +10,15 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"time"
+
+	"github.com/sbondCo/Watcharr/httpc"
 )
 
+var httpClient = httpc.New("arr", httpc.Options{Timeout: 30 * time.Second, MaxRetries: 2})
+
 type ArrType string
 
 var (
@@ -39,6 +45,9 @@ type ArrRequest struct {
 	Title           string `json:"title"` // content name
 	Year            int    `json:"year"`  // content year
 	TMDBID          int    `json:"tmdbId"`
+	// If the user wants the content in 4K, used for routing
+	// requests that don't provide a server.
+	Is4K bool `json:"is4k"`
 }
 
 type SonarrRequest struct {
@@ -53,6 +62,14 @@ type RadarrRequest struct {
 	ArrRequest
 }
 
+// Request for more seasons of a series already on sonarr.
+type SonarrSeasonsRequest struct {
+	ServerName string `json:"serverName"`
+	TMDBID     int    `json:"tmdbId"`
+	// Season numbers wanted.
+	Seasons []int `json:"seasons"`
+}
+
 type SonarrSeasons struct {
 	SeasonNumber int  `json:"seasonNumber"`
 	Monitored    bool `json:"monitored"`
@@ -112,6 +129,59 @@ func (a *Arr) RunCommand(name string) (CommandResponse, error) {
 	return resp, nil
 }
 
+// Set seasons of a series as monitored (others are left as is),
+// optionally searching for the newly monitored seasons.
+func (a *Arr) MonitorSeasons(seriesId int, seasons []int, search bool) error {
+	slog.Debug("MonitorSeasons", "seriesId", seriesId, "seasons", seasons, "type", a.Type, "host", *a.Host, "key", *a.Key)
+	if a.Type != SONARR {
+		return errors.New("invalid arr type")
+	}
+	// Get the full series, so we can send it back unchanged
+	// other than the monitored flags.
+	var series map[string]interface{}
+	seriesIdStr := strconv.Itoa(seriesId)
+	_, err := request(*a.Host, "/series/"+seriesIdStr, map[string]string{"apikey": *a.Key}, &series)
+	if err != nil {
+		slog.Error("MonitorSeasons get series request failed", "seriesId", seriesId, "error", err)
+		return errors.New("request to service failed")
+	}
+	ss, ok := series["seasons"].([]interface{})
+	if !ok {
+		slog.Error("MonitorSeasons: Series has no seasons", "seriesId", seriesId)
+		return errors.New("series has no seasons")
+	}
+	for _, s := range ss {
+		season, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sn, ok := season["seasonNumber"].(float64)
+		if !ok {
+			continue
+		}
+		if slices.Contains(seasons, int(sn)) {
+			season["monitored"] = true
+		}
+	}
+	var resp map[string]interface{}
+	err = requestPut(*a.Host, "/series/"+seriesIdStr, *a.Key, series, &resp)
+	if err != nil {
+		slog.Error("MonitorSeasons update series request failed", "seriesId", seriesId, "error", err)
+		return errors.New("request to service failed")
+	}
+	if search {
+		for _, sn := range seasons {
+			var cmdResp CommandResponse
+			err := requestPost(*a.Host, "/command", *a.Key, map[string]interface{}{"name": "SeasonSearch", "seriesId": seriesId, "seasonNumber": sn}, &cmdResp)
+			if err != nil {
+				// Seasons are monitored, so sonarr will pick them up eventually anyway.
+				slog.Error("MonitorSeasons season search request failed", "seriesId", seriesId, "seasonNumber", sn, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
 // arrId = movieId/seriesId on radarr/sonarr
 func (a *Arr) GetQueueDetails(arrId string, resp interface{}) error {
 	slog.Debug("GetQueueDetails", "arrId", arrId, "type", a.Type, "host", *a.Host, "key", *a.Key)
@@ -148,6 +218,22 @@ func (a *Arr) GetContent(arrId int) (MovieSerie, int, error) {
 	return resp, respStatusCode, nil
 }
 
+// Get all movies/series on the server.
+func (a *Arr) GetAllContent() ([]MovieSerie, error) {
+	slog.Debug("GetAllContent", "type", a.Type, "host", *a.Host, "key", *a.Key)
+	e := "movie"
+	if a.Type == SONARR {
+		e = "series"
+	}
+	var resp []MovieSerie
+	_, err := request(*a.Host, "/"+e, map[string]string{"apikey": *a.Key}, &resp)
+	if err != nil {
+		slog.Error("GetAllContent request failed", "service", a.Type, "error", err)
+		return []MovieSerie{}, errors.New("request to service failed")
+	}
+	return resp, nil
+}
+
 func (a *Arr) LookupByTmdbId(tmdbId int) ([]MovieSerie, error) {
 	slog.Debug("LookupByTmdbId", "tmdbId", tmdbId, "type", a.Type, "host", *a.Host, "key", *a.Key)
 	e := "movie"
@@ -235,7 +321,7 @@ func request(host string, ep string, p map[string]string, resp interface{}) (int
 	base.RawQuery = params.Encode()
 
 	// Run get request
-	res, err := http.Get(base.String())
+	res, err := httpClient.Get(base.String())
 	if err != nil {
 		if res != nil {
 			return res.StatusCode, err
@@ -281,7 +367,53 @@ func requestPost(host string, ep string, key string, p map[string]interface{}, r
 	if err != nil {
 		return err
 	}
-	res, err = http.Post(base.String(), "application/json", bytes.NewBuffer(jsonp))
+	res, err = httpClient.Post(base.String(), "application/json", bytes.NewBuffer(jsonp))
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
+		slog.Error("arr non 2xx status code:", "status_code", res.StatusCode)
+		return errors.New(string(body))
+	}
+	err = json.Unmarshal([]byte(body), &resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func requestPut(host string, ep string, key string, p map[string]interface{}, resp interface{}) error {
+	base, err := url.Parse(host)
+	if err != nil {
+		return errors.New("failed to parse api uri")
+	}
+
+	// Path params
+	base.Path += "/api/v3" + ep
+
+	// Query params
+	params := url.Values{}
+	params.Add("apikey", key)
+
+	// Add params to url
+	base.RawQuery = params.Encode()
+
+	jsonp, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, base.String(), bytes.NewBuffer(jsonp))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -134,10 +134,32 @@ type SonarrQueueDetails []struct {
 // From `GET /movie/{id}` or `GET /series/{id}`.
 // Not all fields described here, just the wanted ones.
 type MovieSerie struct {
-	Title         string    `json:"title"`
-	OriginalTitle string    `json:"originalTitle"`
-	ID            int       `json:"id"`
-	HasFile       bool      `json:"hasFile"`
-	IsAvailable   bool      `json:"isAvailable"`
-	Added         time.Time `json:"added"`
+	Title         string `json:"title"`
+	OriginalTitle string `json:"originalTitle"`
+	ID            int    `json:"id"`
+	TmdbID        int    `json:"tmdbId"`
+	// Only returned from sonarr.
+	TvdbID      int       `json:"tvdbId"`
+	Monitored   bool      `json:"monitored"`
+	HasFile     bool      `json:"hasFile"`
+	IsAvailable bool      `json:"isAvailable"`
+	Added       time.Time `json:"added"`
+	// Only returned from sonarr.
+	Seasons []SonarrSeasons `json:"seasons"`
+	// Only returned from sonarr.
+	Statistics struct {
+		EpisodeFileCount  int     `json:"episodeFileCount"`
+		EpisodeCount      int     `json:"episodeCount"`
+		TotalEpisodeCount int     `json:"totalEpisodeCount"`
+		PercentOfEpisodes float64 `json:"percentOfEpisodes"`
+	} `json:"statistics"`
+}
+
+// If the movie has a file or the series has a file
+// for every monitored (aired) episode.
+func (m *MovieSerie) IsDownloaded() bool {
+	if m.HasFile {
+		return true
+	}
+	return m.Statistics.EpisodeCount > 0 && m.Statistics.EpisodeFileCount >= m.Statistics.EpisodeCount
 }
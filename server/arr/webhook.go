@@ -0,0 +1,45 @@
+package arr
+
+type WebhookEventType string
+
+var (
+	WEBHOOK_TEST          WebhookEventType = "Test"
+	WEBHOOK_GRAB          WebhookEventType = "Grab"
+	WEBHOOK_DOWNLOAD      WebhookEventType = "Download"
+	WEBHOOK_MOVIE_DELETE  WebhookEventType = "MovieDelete"
+	WEBHOOK_SERIES_DELETE WebhookEventType = "SeriesDelete"
+)
+
+// Body sent by sonarr/radarr "Connect" webhooks.
+// Not all fields described here, just the wanted ones.
+// Upgrades are sent as a `Download` event with `IsUpgrade` set.
+type WebhookPayload struct {
+	EventType WebhookEventType `json:"eventType"`
+	IsUpgrade bool             `json:"isUpgrade"`
+	// Only sent from radarr.
+	Movie *struct {
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
+		TmdbID int    `json:"tmdbId"`
+	} `json:"movie,omitempty"`
+	// Only sent from sonarr.
+	Series *struct {
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
+		TvdbID int    `json:"tvdbId"`
+	} `json:"series,omitempty"`
+	DownloadClient string `json:"downloadClient"`
+	DownloadID     string `json:"downloadId"`
+}
+
+// Get the movieId/seriesId this event is for.
+// Zero is returned if the event isn't for specific content.
+func (w *WebhookPayload) ArrID() int {
+	if w.Movie != nil {
+		return w.Movie.ID
+	}
+	if w.Series != nil {
+		return w.Series.ID
+	}
+	return 0
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sbondCo/Watcharr/arr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Movie/series that is on one of our sonarr/radarr servers.
+// Kept up to date by the "Index Arr Libraries" task, so we can show
+// users what we already have before they request it.
+type ArrLibraryItem struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+	// Server this item is on.
+	Type       arr.ArrType `json:"-" gorm:"uniqueIndex:arr_library_item;not null"`
+	ServerName string      `json:"serverName" gorm:"uniqueIndex:arr_library_item;not null"`
+	ArrID      int         `json:"-" gorm:"uniqueIndex:arr_library_item;not null"`
+	TmdbID     int         `json:"-" gorm:"index"`
+	TvdbID     int         `json:"-" gorm:"index"`
+	Monitored  bool        `json:"monitored"`
+	// Movie has a file or series has a file for all monitored (aired) episodes.
+	Downloaded bool `json:"downloaded"`
+	// Number of monitored (aired) episodes without a file (sonarr only).
+	MissingEpisodes int `json:"missingEpisodes"`
+}
+
+// Add all movies/series from our servers to the library index,
+// removing items that are no longer on them.
+func indexArrLibraries(db *gorm.DB) {
+	slog.Debug("indexArrLibraries: Indexing arr libraries.")
+	type server struct {
+		t    arr.ArrType
+		name string
+		a    *arr.Arr
+	}
+	servers := []server{}
+	for _, s := range Config.SONARR {
+		servers = append(servers, server{t: arr.SONARR, name: s.Name, a: arr.New(arr.SONARR, &s.Host, &s.Key)})
+	}
+	for _, s := range Config.RADARR {
+		servers = append(servers, server{t: arr.RADARR, name: s.Name, a: arr.New(arr.RADARR, &s.Host, &s.Key)})
+	}
+	names := map[arr.ArrType][]string{arr.SONARR: {}, arr.RADARR: {}}
+	for _, s := range servers {
+		names[s.t] = append(names[s.t], s.name)
+		started := time.Now()
+		content, err := s.a.GetAllContent()
+		if err != nil {
+			// Leave existing items alone, server is probably just unreachable right now.
+			slog.Error("indexArrLibraries: Failed to get content from server", "type", s.t, "server_name", s.name, "error", err)
+			continue
+		}
+		items := make([]ArrLibraryItem, len(content))
+		for i, c := range content {
+			items[i] = ArrLibraryItem{
+				Type:       s.t,
+				ServerName: s.name,
+				ArrID:      c.ID,
+				TmdbID:     c.TmdbID,
+				TvdbID:     c.TvdbID,
+				Monitored:  c.Monitored,
+				Downloaded: c.IsDownloaded(),
+			}
+			if s.t == arr.SONARR {
+				items[i].MissingEpisodes = max(c.Statistics.EpisodeCount-c.Statistics.EpisodeFileCount, 0)
+			}
+		}
+		if len(items) > 0 {
+			res := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "type"}, {Name: "server_name"}, {Name: "arr_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"updated_at", "tmdb_id", "tvdb_id", "monitored", "downloaded", "missing_episodes"}),
+			}).CreateInBatches(&items, 500)
+			if res.Error != nil {
+				slog.Error("indexArrLibraries: Failed to save items", "type", s.t, "server_name", s.name, "error", res.Error)
+				continue
+			}
+		}
+		// Anything not updated this run is no longer on the server.
+		res := db.Where("type = ? AND server_name = ? AND updated_at < ?", s.t, s.name, started).Delete(&ArrLibraryItem{})
+		if res.Error != nil {
+			slog.Error("indexArrLibraries: Failed to remove old items", "type", s.t, "server_name", s.name, "error", res.Error)
+		}
+		slog.Debug("indexArrLibraries: Indexed server", "type", s.t, "server_name", s.name, "items", len(items), "removed", res.RowsAffected)
+	}
+	// Remove items for servers that no longer exist.
+	for t, n := range names {
+		if res := db.Where("type = ? AND server_name NOT IN ?", t, append(n, "")).Delete(&ArrLibraryItem{}); res.Error != nil {
+			slog.Error("indexArrLibraries: Failed to remove items for removed servers", "type", t, "error", res.Error)
+		}
+	}
+}
+
+// Get availability of content on our servers, keyed by tmdb id.
+func getArrAvailability(db *gorm.DB, contentType ContentType, tmdbIds []int) map[int][]ArrLibraryItem {
+	t := arr.RADARR
+	if contentType == SHOW {
+		t = arr.SONARR
+	}
+	items := []ArrLibraryItem{}
+	avail := map[int][]ArrLibraryItem{}
+	if len(tmdbIds) == 0 {
+		return avail
+	}
+	if res := db.Where("type = ? AND tmdb_id IN ?", t, tmdbIds).Find(&items); res.Error != nil {
+		slog.Error("getArrAvailability: Failed to get items from db", "error", res.Error)
+		return avail
+	}
+	for _, i := range items {
+		avail[i.TmdbID] = append(avail[i.TmdbID], i)
+	}
+	return avail
+}
+
+// Get availability of a show on our servers.
+// Older sonarr versions don't give us tmdb ids, so tvdb id is also checked.
+func getShowArrAvailability(db *gorm.DB, tmdbId int, tvdbId int) []ArrLibraryItem {
+	items := []ArrLibraryItem{}
+	q := db.Where("type = ? AND tmdb_id = ?", arr.SONARR, tmdbId)
+	if tvdbId != 0 {
+		q = db.Where("type = ? AND (tmdb_id = ? OR tvdb_id = ?)", arr.SONARR, tmdbId, tvdbId)
+	}
+	if res := q.Find(&items); res.Error != nil {
+		slog.Error("getShowArrAvailability: Failed to get items from db", "error", res.Error)
+	}
+	return items
+}
+
+// Writer that holds onto a response, so it can be changed before being sent.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+// Middleware that adds `arrAvailability` to content details (of `contentType`)
+// or search results (when `contentType` is empty) responses.
+// Used before the page cache, so availability stays as fresh as our index.
+func WithArrAvailability(db *gorm.DB, contentType ContentType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		body := w.body.Bytes()
+		if w.status == http.StatusOK {
+			if b, err := addArrAvailability(db, contentType, body); err != nil {
+				slog.Error("WithArrAvailability: Failed to add availability to response", "error", err)
+			} else {
+				body = b
+			}
+		}
+		c.Writer.Header().Del("Content-Length")
+		c.Writer.WriteHeader(w.status)
+		c.Writer.Write(body)
+	}
+}
+
+// Add availability to a content details or search results json response.
+func addArrAvailability(db *gorm.DB, contentType ContentType, body []byte) ([]byte, error) {
+	var resp map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&resp); err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		id := jsonInt(resp["id"])
+		var avail []ArrLibraryItem
+		if contentType == SHOW {
+			var tvdbId int
+			if ids, ok := resp["external_ids"].(map[string]interface{}); ok {
+				tvdbId = jsonInt(ids["tvdb_id"])
+			}
+			avail = getShowArrAvailability(db, id, tvdbId)
+		} else {
+			avail = getArrAvailability(db, contentType, []int{id})[id]
+		}
+		if len(avail) > 0 {
+			resp["arrAvailability"] = avail
+		}
+		return json.Marshal(resp)
+	}
+	results, _ := resp["results"].([]interface{})
+	ids := map[ContentType][]int{}
+	for _, r := range results {
+		if r, ok := r.(map[string]interface{}); ok {
+			t := ContentType(fmt.Sprint(r["media_type"]))
+			ids[t] = append(ids[t], jsonInt(r["id"]))
+		}
+	}
+	avail := map[ContentType]map[int][]ArrLibraryItem{
+		MOVIE: getArrAvailability(db, MOVIE, ids[MOVIE]),
+		SHOW:  getArrAvailability(db, SHOW, ids[SHOW]),
+	}
+	for _, r := range results {
+		if r, ok := r.(map[string]interface{}); ok {
+			t := ContentType(fmt.Sprint(r["media_type"]))
+			if a := avail[t][jsonInt(r["id"])]; len(a) > 0 {
+				r["arrAvailability"] = a
+			}
+		}
+	}
+	return json.Marshal(resp)
+}
+
+// Get int from a json.Number, zero if it isn't one.
+func jsonInt(v interface{}) int {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0
+	}
+	i, _ := strconv.Atoi(n.String())
+	return i
+}
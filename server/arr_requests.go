@@ -22,6 +22,17 @@ const (
 	ARR_REQUEST_DENIED ArrRequestStatus = "DENIED"
 	// Content was found on sonarr/radarr already, nothing needs to be done.
 	ARR_REQUEST_FOUND ArrRequestStatus = "FOUND"
+	// Content has finished downloading and is available to watch.
+	ARR_REQUEST_AVAILABLE ArrRequestStatus = "AVAILABLE"
+)
+
+type ArrRequestType string
+
+const (
+	// Request for content to be added to sonarr/radarr.
+	ARR_REQUEST_TYPE_CONTENT ArrRequestType = "CONTENT"
+	// Request for more seasons of a series already on sonarr.
+	ARR_REQUEST_TYPE_SEASONS ArrRequestType = "SEASONS"
 )
 
 type ArrRequest struct {
@@ -34,15 +45,25 @@ type ArrRequest struct {
 	// We don't want to send back the entire user object, just their name.
 	// Not stored in DB, only used for our response from api.
 	Username  string   `json:"username" gorm:"-"`
-	ContentID *int     `json:"-" gorm:"uniqueIndex:sn_to_cid;not null"`
+	ContentID *int     `json:"-" gorm:"uniqueIndex:arr_request_unique;not null"`
 	Content   *Content `json:"content,omitempty"`
 	// Server names are used as an identifier
-	ServerName string `json:"serverName" gorm:"uniqueIndex:sn_to_cid;not null"`
+	ServerName string `json:"serverName" gorm:"uniqueIndex:arr_request_unique;not null"`
+	// Type of request.
+	Type ArrRequestType `json:"type" gorm:"uniqueIndex:arr_request_unique;not null;default:CONTENT"`
+	// Season numbers requested, comma separated (only for ARR_REQUEST_TYPE_SEASONS).
+	RequestedSeasons string `json:"requestedSeasons,omitempty" gorm:"uniqueIndex:arr_request_unique;not null;default:''"`
 	// Sonarr/Radarrs seriesId/movieId
 	ArrID int `json:"arrId"`
 	// Tracked request status
 	Status ArrRequestStatus `json:"status" gorm:"default:PENDING"`
-	// Full request made by user (arr.SonarrRequest / arr.RadarrRequest)
+	// Reason given by admin when denying the request.
+	DenyReason string `json:"denyReason,omitempty"`
+	// Last known download state, kept up to date by sonarr/radarr webhooks.
+	DownloadState ArrDownloadState `json:"downloadState,omitempty"`
+	// Number of seasons requested, used for request quotas (sonarr only).
+	SeasonCount int `json:"-"`
+	// Full request made by user (arr.SonarrRequest / arr.RadarrRequest / arr.SonarrSeasonsRequest)
 	// so we know how to fulfil the request if approved.
 	RequestJson string `json:"requestJson"`
 }
@@ -53,6 +74,9 @@ func deleteArrRequest(db *gorm.DB, id uint) error {
 		slog.Error("deleteArrRequest: Failed to remove from db", "error", resp.Error)
 		return errors.New("failed when removing request")
 	}
+	if err := deleteArrRequestHistory(db, id); err != nil {
+		slog.Error("deleteArrRequest: Failed to remove request history from db", "error", err)
+	}
 	return nil
 }
 
@@ -72,7 +96,7 @@ func getArrRequests(db *gorm.DB) ([]ArrRequest, error) {
 
 func getArrRequest(db *gorm.DB, requestId uint) (ArrRequest, error) {
 	var req ArrRequest
-	resp := db.Where("id = ?", requestId).Take(&req)
+	resp := db.Where("id = ?", requestId).Preload("Content").Take(&req)
 	if resp.Error != nil {
 		slog.Error("getArrRequest: Failed to search for request in db", "error", resp.Error)
 		return ArrRequest{}, errors.New("failed to find request")
@@ -82,7 +106,10 @@ func getArrRequest(db *gorm.DB, requestId uint) (ArrRequest, error) {
 
 func getArrRequestByTmdbId(db *gorm.DB, contentType ContentType, tmdbId int) (ArrRequest, error) {
 	var req ArrRequest
-	resp := db.Joins("JOIN contents ON contents.id = arr_requests.content_id AND contents.tmdb_id = ? AND contents.type = ?", tmdbId, contentType).Find(&req)
+	resp := db.
+		Joins("JOIN contents ON contents.id = arr_requests.content_id AND contents.tmdb_id = ? AND contents.type = ?", tmdbId, contentType).
+		Where("arr_requests.type = ?", ARR_REQUEST_TYPE_CONTENT).
+		Find(&req)
 	if resp.Error != nil {
 		slog.Error("getArrRequestByTmdbId: Failed to search for request in db", "error", resp.Error)
 		return ArrRequest{}, errors.New("failed to find request")
@@ -90,34 +117,64 @@ func getArrRequestByTmdbId(db *gorm.DB, contentType ContentType, tmdbId int) (Ar
 	return req, nil
 }
 
-func createArrRequest(db *gorm.DB, userId uint, serverName string, contentType ContentType, tmdbId int, reqJson string) (*ArrRequest, error) {
+// Create `req` in the db for content, `req.ContentID` is set for us.
+// The users request quota is checked in the same transaction,
+// so concurrent requests can't both get past it.
+func createArrRequest(db *gorm.DB, req ArrRequest, userPerms int, contentType ContentType, tmdbId int) (*ArrRequest, error) {
 	content, err := getOrCacheContent(db, contentType, tmdbId)
 	if err != nil {
 		slog.Error("createArrRequest: getOrCacheContent errored.")
 		return &ArrRequest{}, err
 	}
-	req := ArrRequest{UserID: userId, ServerName: serverName, ContentID: &content.ID, RequestJson: reqJson}
-	resp := db.Create(&req)
-	if resp.Error != nil {
+	req.ContentID = &content.ID
+	if req.Type == "" {
+		req.Type = ARR_REQUEST_TYPE_CONTENT
+	}
+	quotaCount := 1
+	if contentType == SHOW {
+		quotaCount = req.SeasonCount
+	}
+	var quotaErr error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if quotaErr = checkArrRequestQuota(tx, req.UserID, userPerms, contentType, quotaCount); quotaErr != nil {
+			return quotaErr
+		}
+		return tx.Create(&req).Error
+	})
+	if quotaErr != nil {
+		return &ArrRequest{}, quotaErr
+	}
+	if err != nil {
 		slog.Error("createArrRequest: Failed when inserting request into db.", "error", err)
 		return &ArrRequest{}, errors.New("failed when adding request")
 	}
+	addArrRequestEvent(db, req.ID, &req.UserID, ARR_REQUEST_PENDING, "")
 	return &req, nil
 }
 
 func createSonarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.SonarrRequest) (*ArrRequest, error) {
+	// Pick a server for the user if they haven't.
+	if ur.ServerName == "" {
+		if err := routeSonarrRequest(userId, &ur); err != nil {
+			return &ArrRequest{}, err
+		}
+	}
 	server, err := getSonarr(ur.ServerName)
 	if err != nil {
 		slog.Error("createSonarrRequest: Failed to get server", "error", err)
 		return &ArrRequest{}, errors.New("failed to get server")
 	}
+	seasonCount := sonarrRequestSeasonCount(ur)
 	reqJson, err := json.Marshal(ur)
 	if err != nil {
-		slog.Error("createRadarrRequest: Failed when marshalling json request", "error", err)
+		slog.Error("createSonarrRequest: Failed when marshalling json request", "error", err)
 		return &ArrRequest{}, errors.New("failed when processing request")
 	}
 	// Since we create the request in the db now, we don't have to check for duplicates, a unique constraint will error us here if hit.
-	arrReq, err := createArrRequest(db, userId, ur.ServerName, SHOW, ur.TMDBID, string(reqJson[:]))
+	arrReq, err := createArrRequest(db, ArrRequest{UserID: userId, ServerName: ur.ServerName, SeasonCount: seasonCount, RequestJson: string(reqJson[:])}, userPerms, SHOW, ur.TMDBID)
+	if errors.Is(err, errArrRequestQuotaReached) {
+		return &ArrRequest{}, err
+	}
 	if err != nil {
 		slog.Error("createSonarrRequest: Failed when creating arr request", "error", err)
 		return &ArrRequest{}, errors.New("failed when creating request")
@@ -137,6 +194,8 @@ func createSonarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.SonarrR
 			} else {
 				slog.Debug("createSonarrRequest: Result from lookup had an ID. Request in database has been updated with it.", "arr_id", found.ID)
 				arrReq.ArrID = found.ID
+				arrReq.Status = ARR_REQUEST_FOUND
+				addArrRequestEvent(db, arrReq.ID, nil, ARR_REQUEST_FOUND, "")
 				return arrReq, nil
 			}
 		}
@@ -162,11 +221,18 @@ func createSonarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.SonarrR
 		}
 		arrReq.ArrID = int(arrId)
 		arrReq.Status = ARR_REQUEST_AUTO_APPROVED
+		addArrRequestEvent(db, arrReq.ID, &userId, ARR_REQUEST_AUTO_APPROVED, "")
 	}
 	return arrReq, nil
 }
 
 func createRadarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.RadarrRequest) (*ArrRequest, error) {
+	// Pick a server for the user if they haven't.
+	if ur.ServerName == "" {
+		if err := routeRadarrRequest(userId, &ur); err != nil {
+			return &ArrRequest{}, err
+		}
+	}
 	server, err := getRadarr(ur.ServerName)
 	if err != nil {
 		slog.Error("createRadarrRequest: Failed to get server", "error", err)
@@ -178,7 +244,10 @@ func createRadarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.RadarrR
 		return &ArrRequest{}, errors.New("failed when processing request")
 	}
 	// Since we create the request in the db now, we don't have to check for duplicates, a unique constraint will error us here if hit.
-	arrReq, err := createArrRequest(db, userId, ur.ServerName, MOVIE, ur.TMDBID, string(reqJson[:]))
+	arrReq, err := createArrRequest(db, ArrRequest{UserID: userId, ServerName: ur.ServerName, RequestJson: string(reqJson[:])}, userPerms, MOVIE, ur.TMDBID)
+	if errors.Is(err, errArrRequestQuotaReached) {
+		return &ArrRequest{}, err
+	}
 	if err != nil {
 		slog.Error("createRadarrRequest: Failed when creating arr request", "error", err)
 		return &ArrRequest{}, errors.New("failed when creating request")
@@ -198,6 +267,8 @@ func createRadarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.RadarrR
 			} else {
 				slog.Debug("createRadarrRequest: Result from lookup had an ID. Request in database has been updated with it.", "arr_id", found.ID)
 				arrReq.ArrID = found.ID
+				arrReq.Status = ARR_REQUEST_FOUND
+				addArrRequestEvent(db, arrReq.ID, nil, ARR_REQUEST_FOUND, "")
 				return arrReq, nil
 			}
 		}
@@ -223,6 +294,7 @@ func createRadarrRequest(db *gorm.DB, userId uint, userPerms int, ur arr.RadarrR
 		}
 		arrReq.ArrID = int(arrId)
 		arrReq.Status = ARR_REQUEST_AUTO_APPROVED
+		addArrRequestEvent(db, arrReq.ID, &userId, ARR_REQUEST_AUTO_APPROVED, "")
 	}
 	return arrReq, nil
 }
@@ -246,7 +318,7 @@ func getRadarrRequestInfo(db *gorm.DB, requestId uint) (arr.MovieSerie, error) {
 	resp, respStatusCode, err := radarr.GetContent(arrRequest.ArrID)
 	if err != nil {
 		slog.Error("radarr info: Failed to get info", "error", err)
-		if (arrRequest.Status == ARR_REQUEST_APPROVED || arrRequest.Status == ARR_REQUEST_AUTO_APPROVED) && respStatusCode == 404 {
+		if (arrRequest.Status == ARR_REQUEST_APPROVED || arrRequest.Status == ARR_REQUEST_AUTO_APPROVED || arrRequest.Status == ARR_REQUEST_AVAILABLE) && respStatusCode == 404 {
 			slog.Error("radarr info: 404 returned.. content must've been removed.. removing request.")
 			err := deleteArrRequest(db, arrRequest.ID)
 			if err != nil {
@@ -279,7 +351,7 @@ func getSonarrRequestInfo(db *gorm.DB, requestId uint) (arr.MovieSerie, error) {
 	resp, respStatusCode, err := sonarr.GetContent(arrRequest.ArrID)
 	if err != nil {
 		slog.Error("sonarr info: Failed to get info", "error", err)
-		if (arrRequest.Status == ARR_REQUEST_APPROVED || arrRequest.Status == ARR_REQUEST_AUTO_APPROVED) && respStatusCode == 404 {
+		if (arrRequest.Status == ARR_REQUEST_APPROVED || arrRequest.Status == ARR_REQUEST_AUTO_APPROVED || arrRequest.Status == ARR_REQUEST_AVAILABLE) && respStatusCode == 404 {
 			slog.Error("sonarr info: 404 returned.. content must've been removed.. removing request.")
 			err := deleteArrRequest(db, arrRequest.ID)
 			if err != nil {
@@ -292,3 +364,91 @@ func getSonarrRequestInfo(db *gorm.DB, requestId uint) (arr.MovieSerie, error) {
 	}
 	return resp, nil
 }
+
+// Notify the user that made a request that its status has changed.
+// Only statuses the user would not have already seen (when making
+// the request) result in a notification.
+func notifyArrRequestStatusChanged(db *gorm.DB, req ArrRequest, status ArrRequestStatus) {
+	title := "your requested content"
+	if req.Content != nil && req.Content.Title != "" {
+		title = req.Content.Title
+	}
+	var nr NotificationAddRequest
+	switch status {
+	case ARR_REQUEST_APPROVED:
+		nr = NotificationAddRequest{Type: NOTIFICATION_ARR_REQUEST_APPROVED, Message: "Your request for " + title + " has been approved."}
+	case ARR_REQUEST_DENIED:
+		nr = NotificationAddRequest{Type: NOTIFICATION_ARR_REQUEST_DENIED, Message: "Your request for " + title + " has been denied."}
+		if req.DenyReason != "" {
+			nr.Message += " Reason: " + req.DenyReason
+		}
+	case ARR_REQUEST_AVAILABLE:
+		nr = NotificationAddRequest{Type: NOTIFICATION_ARR_REQUEST_AVAILABLE, Message: title + " is now available to watch."}
+	default:
+		slog.Debug("notifyArrRequestStatusChanged: Status does not warrant a notification.", "status", status)
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{"requestId": req.ID, "serverName": req.ServerName, "status": status})
+	if err != nil {
+		slog.Error("notifyArrRequestStatusChanged: Failed to marshal notification data, adding without data", "error", err)
+	} else {
+		nr.Data = string(data)
+	}
+	nr.ContentID = req.ContentID
+	if _, err := addNotification(db, req.UserID, nr); err != nil {
+		slog.Error("notifyArrRequestStatusChanged: Failed to notify user", "request_id", req.ID, "user_id", req.UserID, "error", err)
+	}
+}
+
+// Check if content for our approved requests has finished downloading,
+// if so, the request is marked as available and the requester notified.
+// Radarr movies are available when they have a file, Sonarr series
+// when all monitored (aired) episodes have a file.
+func checkArrRequestsAvailable(db *gorm.DB) {
+	slog.Debug("checkArrRequestsAvailable: Checking for requests with newly available content.")
+	var reqs []ArrRequest
+	resp := db.
+		Preload("Content").
+		Where("status IN ? AND arr_id != 0", []ArrRequestStatus{ARR_REQUEST_APPROVED, ARR_REQUEST_AUTO_APPROVED, ARR_REQUEST_FOUND}).
+		Find(&reqs)
+	if resp.Error != nil {
+		slog.Error("checkArrRequestsAvailable: Failed to get requests from db", "error", resp.Error)
+		return
+	}
+	for _, r := range reqs {
+		if r.Content == nil {
+			continue
+		}
+		var a *arr.Arr
+		if r.Content.Type == MOVIE {
+			server, err := getRadarr(r.ServerName)
+			if err != nil {
+				slog.Error("checkArrRequestsAvailable: Failed to get server", "server_name", r.ServerName, "error", err)
+				continue
+			}
+			a = arr.New(arr.RADARR, &server.Host, &server.Key)
+		} else {
+			server, err := getSonarr(r.ServerName)
+			if err != nil {
+				slog.Error("checkArrRequestsAvailable: Failed to get server", "server_name", r.ServerName, "error", err)
+				continue
+			}
+			a = arr.New(arr.SONARR, &server.Host, &server.Key)
+		}
+		content, _, err := a.GetContent(r.ArrID)
+		if err != nil {
+			slog.Error("checkArrRequestsAvailable: Failed to get content from server", "request_id", r.ID, "error", err)
+			continue
+		}
+		if !content.IsDownloaded() {
+			continue
+		}
+		slog.Info("checkArrRequestsAvailable: Requested content is now available.", "request_id", r.ID, "arr_id", r.ArrID)
+		if res := db.Model(&ArrRequest{}).Where("id = ?", r.ID).Update("status", ARR_REQUEST_AVAILABLE); res.Error != nil {
+			slog.Error("checkArrRequestsAvailable: Failed to update request status", "request_id", r.ID, "error", res.Error)
+			continue
+		}
+		addArrRequestEvent(db, r.ID, nil, ARR_REQUEST_AVAILABLE, "")
+		notifyArrRequestStatusChanged(db, r, ARR_REQUEST_AVAILABLE)
+	}
+}
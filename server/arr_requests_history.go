@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Record of a requests status changing (or being created).
+type ArrRequestEvent struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ArrRequestID uint      `json:"-" gorm:"not null;index"`
+	// User that caused this event, nil when done by watcharr itself
+	// (ex, content found on server or finished downloading).
+	UserID *uint `json:"-"`
+	User   *User `json:"-"`
+	// Username of `User`, not stored in DB.
+	Username string `json:"username,omitempty" gorm:"-"`
+	// Status the request was changed to.
+	Status ArrRequestStatus `json:"status" gorm:"not null"`
+	// Reason given for the change (ex, why a request was denied).
+	Reason string `json:"reason,omitempty"`
+}
+
+// Comment left on a request, by the requester or an admin.
+type ArrRequestComment struct {
+	GormModel
+	ArrRequestID uint `json:"-" gorm:"not null;index"`
+	UserID       uint `json:"-" gorm:"not null"`
+	User         User `json:"-"`
+	// Username of `User`, not stored in DB.
+	Username string `json:"username" gorm:"-"`
+	Comment  string `json:"comment" gorm:"not null"`
+}
+
+type ArrRequestCommentAddRequest struct {
+	Comment string `json:"comment" binding:"required,max=1000"`
+}
+
+type ArrRequestDenyRequest struct {
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+type ArrRequestHistoryResponse struct {
+	Events   []ArrRequestEvent   `json:"events"`
+	Comments []ArrRequestComment `json:"comments"`
+}
+
+// Record an event in a requests history. Failures are only
+// logged, we don't want to fail whatever caused the event.
+func addArrRequestEvent(db *gorm.DB, requestId uint, userId *uint, status ArrRequestStatus, reason string) {
+	res := db.Create(&ArrRequestEvent{ArrRequestID: requestId, UserID: userId, Status: status, Reason: reason})
+	if res.Error != nil {
+		slog.Error("addArrRequestEvent: Failed to add event to db", "request_id", requestId, "status", status, "error", res.Error)
+	}
+}
+
+// Get a request, only if the user is allowed to view it
+// (they made the request or they are an admin).
+func getArrRequestForUser(db *gorm.DB, requestId uint, userId uint, userPerms int) (ArrRequest, error) {
+	req, err := getArrRequest(db, requestId)
+	if err != nil {
+		return ArrRequest{}, err
+	}
+	if req.UserID != userId && !hasPermission(userPerms, PERM_ADMIN) {
+		slog.Warn("getArrRequestForUser: User tried to access request that isn't theirs", "request_id", requestId, "user_id", userId)
+		return ArrRequest{}, errors.New("failed to find request")
+	}
+	return req, nil
+}
+
+func getArrRequestHistory(db *gorm.DB, requestId uint, userId uint, userPerms int) (ArrRequestHistoryResponse, error) {
+	if _, err := getArrRequestForUser(db, requestId, userId, userPerms); err != nil {
+		return ArrRequestHistoryResponse{}, err
+	}
+	events := []ArrRequestEvent{}
+	if res := db.Preload("User").Where("arr_request_id = ?", requestId).Order("created_at ASC").Find(&events); res.Error != nil {
+		slog.Error("getArrRequestHistory: Failed to get events from db", "error", res.Error)
+		return ArrRequestHistoryResponse{}, errors.New("failed to get request history")
+	}
+	for i := range events {
+		if events[i].User != nil {
+			events[i].Username = events[i].User.Username
+		}
+	}
+	comments := []ArrRequestComment{}
+	if res := db.Preload("User").Where("arr_request_id = ?", requestId).Order("created_at ASC").Find(&comments); res.Error != nil {
+		slog.Error("getArrRequestHistory: Failed to get comments from db", "error", res.Error)
+		return ArrRequestHistoryResponse{}, errors.New("failed to get request history")
+	}
+	for i := range comments {
+		comments[i].Username = comments[i].User.Username
+	}
+	return ArrRequestHistoryResponse{Events: events, Comments: comments}, nil
+}
+
+// Add a comment to a request. When an admin comments on
+// someone elses request, the requester is notified.
+func addArrRequestComment(db *gorm.DB, requestId uint, userId uint, userPerms int, cr ArrRequestCommentAddRequest) (ArrRequestComment, error) {
+	req, err := getArrRequestForUser(db, requestId, userId, userPerms)
+	if err != nil {
+		return ArrRequestComment{}, err
+	}
+	text := strings.TrimSpace(cr.Comment)
+	if text == "" {
+		return ArrRequestComment{}, errors.New("comment can not be empty")
+	}
+	comment := ArrRequestComment{ArrRequestID: requestId, UserID: userId, Comment: text}
+	if res := db.Create(&comment); res.Error != nil {
+		slog.Error("addArrRequestComment: Failed to add comment to db", "error", res.Error)
+		return ArrRequestComment{}, errors.New("failed to add comment")
+	}
+	if res := db.Model(&User{}).Select("username").Where("id = ?", userId).Scan(&comment.Username); res.Error != nil {
+		slog.Error("addArrRequestComment: Failed to get username", "error", res.Error)
+	}
+	if req.UserID != userId {
+		title := "your request"
+		if req.Content != nil && req.Content.Title != "" {
+			title = "your request for " + req.Content.Title
+		}
+		data, _ := json.Marshal(map[string]any{"requestId": req.ID, "commentId": comment.ID})
+		_, err := addNotification(db, req.UserID, NotificationAddRequest{
+			Type:      NOTIFICATION_ARR_REQUEST_COMMENT,
+			Message:   comment.Username + " commented on " + title + ".",
+			Data:      string(data),
+			ContentID: req.ContentID,
+		})
+		if err != nil {
+			slog.Error("addArrRequestComment: Failed to notify requester", "request_id", req.ID, "error", err)
+		}
+	}
+	return comment, nil
+}
+
+// Remove all comments and events for a request.
+func deleteArrRequestHistory(db *gorm.DB, requestId uint) error {
+	if res := db.Where("arr_request_id = ?", requestId).Delete(&ArrRequestEvent{}); res.Error != nil {
+		return res.Error
+	}
+	if res := db.Unscoped().Where("arr_request_id = ?", requestId).Delete(&ArrRequestComment{}); res.Error != nil {
+		return res.Error
+	}
+	return nil
+}
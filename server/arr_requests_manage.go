@@ -3,24 +3,37 @@ package main
 import (
 	"errors"
 	"log/slog"
+	"strings"
 
 	"github.com/sbondCo/Watcharr/arr"
 	"gorm.io/gorm"
 )
 
 // Deny an arr request
-func denyArrRequest(db *gorm.DB, id uint) error {
-	resp := db.Model(&ArrRequest{}).Where("id = ?", id).Update("status", ARR_REQUEST_DENIED)
+func denyArrRequest(db *gorm.DB, id uint, adminId uint, dr ArrRequestDenyRequest) error {
+	reason := strings.TrimSpace(dr.Reason)
+	if reason == "" && Config.ARR_REQUEST_DENY_REASON_REQUIRED {
+		return errors.New("a reason is required when denying a request")
+	}
+	req, err := getArrRequest(db, id)
+	if err != nil {
+		slog.Error("denyArrRequest: Failed to get request from db", "error", err)
+		return errors.New("failed to get request")
+	}
+	resp := db.Model(&ArrRequest{}).Where("id = ?", id).Updates(map[string]interface{}{"status": ARR_REQUEST_DENIED, "deny_reason": reason})
 	if resp.Error != nil {
 		slog.Error("denyArrRequest: Failed to update status to denied", "error", resp.Error)
 		return errors.New("failed when updating request status")
 	}
+	req.DenyReason = reason
+	addArrRequestEvent(db, id, &adminId, ARR_REQUEST_DENIED, reason)
+	notifyArrRequestStatusChanged(db, req, ARR_REQUEST_DENIED)
 	return nil
 }
 
 // Approve radarr movie
-func approveRadarrRequest(db *gorm.DB, reqId uint, ur arr.RadarrRequest) (int, error) {
-	_, err := getArrRequest(db, reqId)
+func approveRadarrRequest(db *gorm.DB, reqId uint, adminId uint, ur arr.RadarrRequest) (int, error) {
+	req, err := getArrRequest(db, reqId)
 	if err != nil {
 		slog.Error("approveRadarrRequest: Failed to get request from db", "error", err)
 		return 0, errors.New("failed to get request")
@@ -43,6 +56,8 @@ func approveRadarrRequest(db *gorm.DB, reqId uint, ur arr.RadarrRequest) (int, e
 		slog.Error("approveRadarrRequest: Failed to update request in db", "error", err)
 		return 0, errors.New("content was requested, but we failed to update the db")
 	}
+	addArrRequestEvent(db, reqId, &adminId, ARR_REQUEST_APPROVED, "")
+	notifyArrRequestStatusChanged(db, req, ARR_REQUEST_APPROVED)
 	arrId, ok := resp["id"].(float64)
 	if !ok {
 		slog.Error("approveRadarrRequest: Failed to cast arr id as an int", "id", resp["id"])
@@ -52,12 +67,16 @@ func approveRadarrRequest(db *gorm.DB, reqId uint, ur arr.RadarrRequest) (int, e
 }
 
 // Approve sonarr movie
-func approveSonarrRequest(db *gorm.DB, reqId uint, ur arr.SonarrRequest) (int, error) {
-	_, err := getArrRequest(db, reqId)
+func approveSonarrRequest(db *gorm.DB, reqId uint, adminId uint, ur arr.SonarrRequest) (int, error) {
+	req, err := getArrRequest(db, reqId)
 	if err != nil {
 		slog.Error("approveSonarrRequest: Failed to get request from db", "error", err)
 		return 0, errors.New("failed to get request")
 	}
+	// Seasons requests already have everything they need stored with them.
+	if req.Type == ARR_REQUEST_TYPE_SEASONS {
+		return approveSonarrSeasonsRequest(db, req, adminId)
+	}
 	// Get server in request
 	server, err := getSonarr(ur.ServerName)
 	if err != nil {
@@ -76,6 +95,8 @@ func approveSonarrRequest(db *gorm.DB, reqId uint, ur arr.SonarrRequest) (int, e
 		slog.Error("approveSonarrRequest: Failed to update request in db", "error", err)
 		return 0, errors.New("content was requested, but we failed to update the db")
 	}
+	addArrRequestEvent(db, reqId, &adminId, ARR_REQUEST_APPROVED, "")
+	notifyArrRequestStatusChanged(db, req, ARR_REQUEST_APPROVED)
 	arrId, ok := resp["id"].(float64)
 	if !ok {
 		slog.Error("approveSonarrRequest: Failed to cast arr id as an int", "id", resp["id"])
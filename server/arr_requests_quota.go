@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sbondCo/Watcharr/arr"
+	"gorm.io/gorm"
+)
+
+// Default rolling window (in days) for request quotas.
+const arrRequestQuotaDefaultDays = 7
+
+var errArrRequestQuotaReached = errors.New("request quota reached")
+
+type ArrRequestQuotaAllowance struct {
+	// Max allowed in the window, zero when unlimited.
+	Limit int `json:"limit"`
+	// How many have been requested in the window.
+	Used int `json:"used"`
+	// How many can still be requested, -1 when unlimited.
+	Remaining int `json:"remaining"`
+}
+
+type ArrRequestQuotaResponse struct {
+	// Length of the rolling window in days.
+	Days    int                      `json:"days"`
+	Movies  ArrRequestQuotaAllowance `json:"movies"`
+	Seasons ArrRequestQuotaAllowance `json:"seasons"`
+}
+
+func newArrRequestQuotaAllowance(limit int, used int) ArrRequestQuotaAllowance {
+	a := ArrRequestQuotaAllowance{Limit: limit, Used: used, Remaining: -1}
+	if limit > 0 {
+		a.Remaining = max(limit-used, 0)
+	}
+	return a
+}
+
+func getArrRequestQuotaDays() int {
+	if Config.ARR_REQUEST_QUOTA_DAYS > 0 {
+		return Config.ARR_REQUEST_QUOTA_DAYS
+	}
+	return arrRequestQuotaDefaultDays
+}
+
+// Number of seasons a sonarr request counts as towards a quota.
+// Requests that don't monitor any seasons still count as one.
+func sonarrRequestSeasonCount(ur arr.SonarrRequest) int {
+	n := 0
+	for _, s := range ur.Seasons {
+		if s.Monitored {
+			n++
+		}
+	}
+	return max(n, 1)
+}
+
+// Get a users request quota and how much of it has been used.
+// Denied requests and requests for content already on the
+// server don't count towards it.
+func getArrRequestQuota(db *gorm.DB, userId uint) (ArrRequestQuotaResponse, error) {
+	var user User
+	if res := db.Select("request_quota_movies", "request_quota_seasons").Where("id = ?", userId).Take(&user); res.Error != nil {
+		slog.Error("getArrRequestQuota: Failed to get user from db", "error", res.Error)
+		return ArrRequestQuotaResponse{}, errors.New("failed to get request quota")
+	}
+	movieLimit := Config.ARR_REQUEST_QUOTA_MOVIES
+	if user.RequestQuotaMovies != nil {
+		movieLimit = *user.RequestQuotaMovies
+	}
+	seasonLimit := Config.ARR_REQUEST_QUOTA_SEASONS
+	if user.RequestQuotaSeasons != nil {
+		seasonLimit = *user.RequestQuotaSeasons
+	}
+	days := getArrRequestQuotaDays()
+	var used struct {
+		Movies  int
+		Seasons int
+	}
+	res := db.Model(&ArrRequest{}).
+		Select("COUNT(CASE WHEN contents.type = ? THEN 1 END) AS movies, COALESCE(SUM(CASE WHEN contents.type = ? THEN arr_requests.season_count END), 0) AS seasons", MOVIE, SHOW).
+		Joins("JOIN contents ON contents.id = arr_requests.content_id").
+		Where("arr_requests.user_id = ? AND arr_requests.created_at > ? AND arr_requests.status NOT IN ?", userId, time.Now().AddDate(0, 0, -days), []ArrRequestStatus{ARR_REQUEST_DENIED, ARR_REQUEST_FOUND}).
+		// Found requests become available once checked, so go by their history.
+		Where("NOT EXISTS (SELECT 1 FROM arr_request_events WHERE arr_request_events.arr_request_id = arr_requests.id AND arr_request_events.status = ?)", ARR_REQUEST_FOUND).
+		Scan(&used)
+	if res.Error != nil {
+		slog.Error("getArrRequestQuota: Failed to count requests in db", "error", res.Error)
+		return ArrRequestQuotaResponse{}, errors.New("failed to get request quota")
+	}
+	return ArrRequestQuotaResponse{
+		Days:    days,
+		Movies:  newArrRequestQuotaAllowance(movieLimit, used.Movies),
+		Seasons: newArrRequestQuotaAllowance(seasonLimit, used.Seasons),
+	}, nil
+}
+
+// Error if the user can't request this many more movies or seasons.
+// Admins are never limited.
+func checkArrRequestQuota(db *gorm.DB, userId uint, userPerms int, contentType ContentType, count int) error {
+	if hasPermission(userPerms, PERM_ADMIN) {
+		return nil
+	}
+	q, err := getArrRequestQuota(db, userId)
+	if err != nil {
+		return err
+	}
+	a := q.Movies
+	what := "movies"
+	if contentType == SHOW {
+		a = q.Seasons
+		what = "seasons"
+	}
+	if a.Limit > 0 && count > a.Remaining {
+		slog.Debug("checkArrRequestQuota: User has hit their request quota", "user_id", userId, "type", contentType, "count", count, "remaining", a.Remaining)
+		return fmt.Errorf("%w, you can only request %d more %s (limit is %d every %d days)", errArrRequestQuotaReached, a.Remaining, what, a.Limit, q.Days)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sbondCo/Watcharr/arr"
+	"gorm.io/gorm"
+)
+
+// Request more seasons of a series that is already on sonarr.
+// Seasons that are already monitored are ignored, the rest
+// go through the same approval flow as normal requests.
+func createSonarrSeasonsRequest(db *gorm.DB, userId uint, userPerms int, ur arr.SonarrSeasonsRequest) (*ArrRequest, error) {
+	if len(ur.Seasons) == 0 {
+		return &ArrRequest{}, errors.New("no seasons requested")
+	}
+	server, err := getSonarr(ur.ServerName)
+	if err != nil {
+		slog.Error("createSonarrSeasonsRequest: Failed to get server", "error", err)
+		return &ArrRequest{}, errors.New("failed to get server")
+	}
+	sonarr := arr.New(arr.SONARR, &server.Host, &server.Key)
+	lookupRes, err := sonarr.LookupByTmdbId(ur.TMDBID)
+	if err != nil {
+		slog.Error("createSonarrSeasonsRequest: Lookup failed", "error", err)
+		return &ArrRequest{}, errors.New("failed to find series on server")
+	}
+	if len(lookupRes) != 1 || lookupRes[0].ID == 0 {
+		return &ArrRequest{}, errors.New("series has not been added to the server yet, request the series instead")
+	}
+	series := lookupRes[0]
+	// Only keep seasons that exist and aren't already monitored.
+	seasons := []int{}
+	for _, s := range series.Seasons {
+		if !s.Monitored && slices.Contains(ur.Seasons, s.SeasonNumber) && !slices.Contains(seasons, s.SeasonNumber) {
+			seasons = append(seasons, s.SeasonNumber)
+		}
+	}
+	if len(seasons) == 0 {
+		return &ArrRequest{}, errors.New("requested seasons are already monitored or do not exist")
+	}
+	slices.Sort(seasons)
+	ur.Seasons = seasons
+	reqJson, err := json.Marshal(ur)
+	if err != nil {
+		slog.Error("createSonarrSeasonsRequest: Failed when marshalling json request", "error", err)
+		return &ArrRequest{}, errors.New("failed when processing request")
+	}
+	sn := make([]string, len(seasons))
+	for i, s := range seasons {
+		sn[i] = strconv.Itoa(s)
+	}
+	arrReq, err := createArrRequest(db, ArrRequest{
+		UserID:           userId,
+		ServerName:       ur.ServerName,
+		Type:             ARR_REQUEST_TYPE_SEASONS,
+		RequestedSeasons: strings.Join(sn, ","),
+		ArrID:            series.ID,
+		SeasonCount:      len(seasons),
+		RequestJson:      string(reqJson[:]),
+	}, userPerms, SHOW, ur.TMDBID)
+	if errors.Is(err, errArrRequestQuotaReached) {
+		return &ArrRequest{}, err
+	}
+	if err != nil {
+		slog.Error("createSonarrSeasonsRequest: Failed when creating arr request", "error", err)
+		return &ArrRequest{}, errors.New("failed when creating request, these seasons may have already been requested")
+	}
+	if hasPermission(userPerms, PERM_REQUEST_CONTENT_AUTO_APPROVE) {
+		slog.Debug("createSonarrSeasonsRequest: User has auto approve permission.. sending request to Sonarr.")
+		if err := sonarr.MonitorSeasons(series.ID, seasons, server.AutomaticSearch); err != nil {
+			slog.Error("createSonarrSeasonsRequest: Failed to monitor seasons", "error", err)
+			return &ArrRequest{}, errors.New("failed to monitor seasons")
+		}
+		dbResp := db.Model(&ArrRequest{}).Where("id = ?", arrReq.ID).Update("status", ARR_REQUEST_AUTO_APPROVED)
+		if dbResp.Error != nil {
+			slog.Error("createSonarrSeasonsRequest: Failed to update request in db", "error", dbResp.Error)
+			return &ArrRequest{}, errors.New("seasons were requested, but we failed to update the db")
+		}
+		arrReq.Status = ARR_REQUEST_AUTO_APPROVED
+		addArrRequestEvent(db, arrReq.ID, &userId, ARR_REQUEST_AUTO_APPROVED, "")
+	}
+	return arrReq, nil
+}
+
+// Approve a seasons request, monitoring the requested seasons on sonarr.
+func approveSonarrSeasonsRequest(db *gorm.DB, req ArrRequest, adminId uint) (int, error) {
+	var ur arr.SonarrSeasonsRequest
+	if err := json.Unmarshal([]byte(req.RequestJson), &ur); err != nil {
+		slog.Error("approveSonarrSeasonsRequest: Failed to unmarshal request json", "request_id", req.ID, "error", err)
+		return 0, errors.New("failed to read request")
+	}
+	server, err := getSonarr(req.ServerName)
+	if err != nil {
+		slog.Error("approveSonarrSeasonsRequest: Failed to get server", "error", err)
+		return 0, errors.New("failed to get server")
+	}
+	sonarr := arr.New(arr.SONARR, &server.Host, &server.Key)
+	if err := sonarr.MonitorSeasons(req.ArrID, ur.Seasons, server.AutomaticSearch); err != nil {
+		slog.Error("approveSonarrSeasonsRequest: Failed to monitor seasons", "error", err)
+		return 0, errors.New("failed to monitor seasons")
+	}
+	dbResp := db.Model(&ArrRequest{}).Where("id = ?", req.ID).Update("status", ARR_REQUEST_APPROVED)
+	if dbResp.Error != nil {
+		slog.Error("approveSonarrSeasonsRequest: Failed to update request in db", "error", dbResp.Error)
+		return 0, errors.New("seasons were requested, but we failed to update the db")
+	}
+	addArrRequestEvent(db, req.ID, &adminId, ARR_REQUEST_APPROVED, "")
+	notifyArrRequestStatusChanged(db, req, ARR_REQUEST_APPROVED)
+	return req.ArrID, nil
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sbondCo/Watcharr/arr"
+)
+
+type ArrServerRole string
+
+const (
+	// Server requests are sent to when no routing rule matches.
+	ARR_SERVER_ROLE_DEFAULT ArrServerRole = "DEFAULT"
+	// Server requests are sent to when 4K is requested and no routing rule matches.
+	ARR_SERVER_ROLE_4K ArrServerRole = "4K"
+)
+
+// Admin defined rule for picking which server (and settings)
+// a request is sent to, so users don't have to choose.
+// All conditions that are set must match, unset conditions match anything.
+// Rules are checked in order, first matching rule wins.
+type ArrRoutingRule struct {
+	Name string      `json:"name"`
+	Type arr.ArrType `json:"type"`
+
+	// Conditions
+	// Match if content has any of these genres (TMDB genre names).
+	Genres []string `json:"genres,omitempty"`
+	// Match if contents original language is any of these (ISO 639-1).
+	OriginalLanguages []string `json:"originalLanguages,omitempty"`
+	// Match only anime (true) or only non anime (false) content.
+	// Content is anime if it has the `anime` keyword on TMDB.
+	Anime *bool `json:"anime,omitempty"`
+	// Match only 4K (true) or only non 4K (false) requests.
+	Is4K *bool `json:"is4k,omitempty"`
+	// Match if request was made by any of these users.
+	UserIDs []uint `json:"userIds,omitempty"`
+
+	// Actions
+	// Server to send request to.
+	ServerName string `json:"serverName"`
+	// Optional overrides of the servers defaults.
+	QualityProfile  int    `json:"qualityProfile,omitempty"`
+	RootFolder      string `json:"rootFolder,omitempty"` // path
+	LanguageProfile int    `json:"languageProfile,omitempty"`
+	SeriesType      string `json:"seriesType,omitempty"` // sonarr only (standard, daily, anime)
+}
+
+// Content details routing rules are matched against.
+type ArrRoutingContent struct {
+	Genres           []string
+	OriginalLanguage string
+	Anime            bool
+}
+
+// Details response with only what we need for routing.
+// Keywords are under `keywords` for movies and `results` for shows.
+type TMDBRoutingDetails struct {
+	Genres []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	OriginalLanguage string `json:"original_language"`
+	Keywords         struct {
+		Keywords []struct {
+			Name string `json:"name"`
+		} `json:"keywords"`
+		Results []struct {
+			Name string `json:"name"`
+		} `json:"results"`
+	} `json:"keywords"`
+}
+
+func getArrRoutingContent(contentType ContentType, tmdbId int) (ArrRoutingContent, error) {
+	ep := "/movie/"
+	if contentType == SHOW {
+		ep = "/tv/"
+	}
+	resp := new(TMDBRoutingDetails)
+	err := tmdbRequest(ep+strconv.Itoa(tmdbId), map[string]string{"append_to_response": "keywords"}, &resp)
+	if err != nil {
+		slog.Error("getArrRoutingContent: Failed to get content details", "error", err)
+		return ArrRoutingContent{}, errors.New("failed to get content details")
+	}
+	rc := ArrRoutingContent{OriginalLanguage: resp.OriginalLanguage}
+	for _, g := range resp.Genres {
+		rc.Genres = append(rc.Genres, g.Name)
+	}
+	for _, k := range append(resp.Keywords.Keywords, resp.Keywords.Results...) {
+		if strings.EqualFold(k.Name, "anime") {
+			rc.Anime = true
+			break
+		}
+	}
+	return rc, nil
+}
+
+func (r *ArrRoutingRule) matches(c ArrRoutingContent, userId uint, is4K bool) bool {
+	if len(r.Genres) > 0 && !slices.ContainsFunc(r.Genres, func(g string) bool {
+		return slices.ContainsFunc(c.Genres, func(cg string) bool { return strings.EqualFold(g, cg) })
+	}) {
+		return false
+	}
+	if len(r.OriginalLanguages) > 0 && !slices.ContainsFunc(r.OriginalLanguages, func(l string) bool { return strings.EqualFold(l, c.OriginalLanguage) }) {
+		return false
+	}
+	if r.Anime != nil && *r.Anime != c.Anime {
+		return false
+	}
+	if r.Is4K != nil && *r.Is4K != is4K {
+		return false
+	}
+	if len(r.UserIDs) > 0 && !slices.Contains(r.UserIDs, userId) {
+		return false
+	}
+	return true
+}
+
+func getArrServers(t arr.ArrType) []ArrSettings {
+	var servers []ArrSettings
+	if t == arr.SONARR {
+		for _, s := range Config.SONARR {
+			servers = append(servers, s.ArrSettings)
+		}
+	} else {
+		for _, s := range Config.RADARR {
+			servers = append(servers, s.ArrSettings)
+		}
+	}
+	return servers
+}
+
+// Error if another server of the same type already has one of the roles
+// given to server `s`, each role can only be used by one server per type.
+func validateArrServerRoles(t arr.ArrType, s ArrSettings) error {
+	for _, role := range s.Roles {
+		if role != ARR_SERVER_ROLE_DEFAULT && role != ARR_SERVER_ROLE_4K {
+			return errors.New("unknown server role")
+		}
+		for _, o := range getArrServers(t) {
+			if o.Name != s.Name && slices.Contains(o.Roles, role) {
+				return errors.New("server " + o.Name + " already has the " + string(role) + " role")
+			}
+		}
+	}
+	return nil
+}
+
+// Find the first rule that matches our request.
+// If none match, a rule is made from the server with the
+// matching role (4K or default) or the only server available.
+func findArrRoutingRule(t arr.ArrType, contentType ContentType, tmdbId int, userId uint, is4K bool) (ArrRoutingRule, error) {
+	servers := getArrServers(t)
+	rules := []ArrRoutingRule{}
+	for _, r := range Config.ARR_ROUTING_RULES {
+		// Skip rules for servers that no longer exist, so others can still be used.
+		if r.Type == t && slices.ContainsFunc(servers, func(s ArrSettings) bool { return s.Name == r.ServerName }) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) > 0 {
+		c, err := getArrRoutingContent(contentType, tmdbId)
+		if err != nil {
+			return ArrRoutingRule{}, err
+		}
+		for _, r := range rules {
+			if r.matches(c, userId, is4K) {
+				slog.Debug("findArrRoutingRule: Rule matched", "rule", r.Name, "server_name", r.ServerName)
+				return r, nil
+			}
+		}
+	}
+	role := ARR_SERVER_ROLE_DEFAULT
+	if is4K {
+		role = ARR_SERVER_ROLE_4K
+	}
+	for _, s := range servers {
+		if slices.Contains(s.Roles, role) {
+			return ArrRoutingRule{Type: t, ServerName: s.Name}, nil
+		}
+	}
+	if len(servers) == 1 && !is4K {
+		return ArrRoutingRule{Type: t, ServerName: servers[0].Name}, nil
+	}
+	return ArrRoutingRule{}, errors.New("no server could be picked for this request, please choose one")
+}
+
+// Get path of a root folder from its id.
+func getArrRootFolderPath(a *arr.Arr, id int) (string, error) {
+	rfs, err := a.GetRootFolders()
+	if err != nil {
+		return "", err
+	}
+	for _, rf := range rfs {
+		if rf.ID == id {
+			return rf.Path, nil
+		}
+	}
+	return "", errors.New("root folder not found")
+}
+
+// Fill in server and its settings for a sonarr request that doesn't
+// name a server, from our routing rules and the servers defaults.
+func routeSonarrRequest(userId uint, ur *arr.SonarrRequest) error {
+	rule, err := findArrRoutingRule(arr.SONARR, SHOW, ur.TMDBID, userId, ur.Is4K)
+	if err != nil {
+		return err
+	}
+	server, err := getSonarr(rule.ServerName)
+	if err != nil {
+		slog.Error("routeSonarrRequest: Routed to server that doesn't exist", "rule", rule.Name, "server_name", rule.ServerName)
+		return errors.New("failed to get server")
+	}
+	ur.ServerName = server.Name
+	ur.QualityProfile = server.QualityProfile
+	if rule.QualityProfile != 0 {
+		ur.QualityProfile = rule.QualityProfile
+	}
+	ur.LanguageProfile = server.LanguageProfile
+	if rule.LanguageProfile != 0 {
+		ur.LanguageProfile = rule.LanguageProfile
+	}
+	if rule.SeriesType != "" {
+		ur.SeriesType = rule.SeriesType
+	} else if ur.SeriesType == "" {
+		ur.SeriesType = "standard"
+	}
+	if rule.RootFolder != "" {
+		ur.RootFolder = rule.RootFolder
+	} else {
+		rf, err := getArrRootFolderPath(arr.New(arr.SONARR, &server.Host, &server.Key), server.RootFolder)
+		if err != nil {
+			slog.Error("routeSonarrRequest: Failed to get root folder path", "server_name", server.Name, "error", err)
+			return errors.New("failed to get root folder for server")
+		}
+		ur.RootFolder = rf
+	}
+	slog.Debug("routeSonarrRequest: Request routed", "rule", rule.Name, "server_name", ur.ServerName)
+	return nil
+}
+
+// Fill in server and its settings for a radarr request that doesn't
+// name a server, from our routing rules and the servers defaults.
+func routeRadarrRequest(userId uint, ur *arr.RadarrRequest) error {
+	rule, err := findArrRoutingRule(arr.RADARR, MOVIE, ur.TMDBID, userId, ur.Is4K)
+	if err != nil {
+		return err
+	}
+	server, err := getRadarr(rule.ServerName)
+	if err != nil {
+		slog.Error("routeRadarrRequest: Routed to server that doesn't exist", "rule", rule.Name, "server_name", rule.ServerName)
+		return errors.New("failed to get server")
+	}
+	ur.ServerName = server.Name
+	ur.QualityProfile = server.QualityProfile
+	if rule.QualityProfile != 0 {
+		ur.QualityProfile = rule.QualityProfile
+	}
+	if rule.RootFolder != "" {
+		ur.RootFolder = rule.RootFolder
+	} else {
+		rf, err := getArrRootFolderPath(arr.New(arr.RADARR, &server.Host, &server.Key), server.RootFolder)
+		if err != nil {
+			slog.Error("routeRadarrRequest: Failed to get root folder path", "server_name", server.Name, "error", err)
+			return errors.New("failed to get root folder for server")
+		}
+		ur.RootFolder = rf
+	}
+	slog.Debug("routeRadarrRequest: Request routed", "rule", rule.Name, "server_name", ur.ServerName)
+	return nil
+}
+
+// Remove routing rules that send requests to a server, for when it is removed.
+func rmArrRoutingRulesForServer(t arr.ArrType, name string) {
+	Config.ARR_ROUTING_RULES = slices.DeleteFunc(Config.ARR_ROUTING_RULES, func(r ArrRoutingRule) bool {
+		return r.Type == t && r.ServerName == name
+	})
+}
+
+func getArrRoutingRules() []ArrRoutingRule {
+	if Config.ARR_ROUTING_RULES == nil {
+		return []ArrRoutingRule{}
+	}
+	return Config.ARR_ROUTING_RULES
+}
+
+// Replace all routing rules (order matters, first matching rule is used).
+func setArrRoutingRules(rules []ArrRoutingRule) error {
+	for _, r := range rules {
+		if r.Type == arr.SONARR {
+			if _, err := getSonarr(r.ServerName); err != nil {
+				return errors.New("rule " + r.Name + " routes to a sonarr server that does not exist")
+			}
+		} else if r.Type == arr.RADARR {
+			if _, err := getRadarr(r.ServerName); err != nil {
+				return errors.New("rule " + r.Name + " routes to a radarr server that does not exist")
+			}
+		} else {
+			return errors.New("rule " + r.Name + " has an invalid type")
+		}
+	}
+	Config.ARR_ROUTING_RULES = rules
+	if err := writeConfig(); err != nil {
+		slog.Error("setArrRoutingRules: Failed to write config", "error", err)
+		return errors.New("failed to save routing rules")
+	}
+	return nil
+}
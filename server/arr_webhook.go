@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/url"
+
+	"github.com/sbondCo/Watcharr/arr"
+	"gorm.io/gorm"
+)
+
+type ArrDownloadState string
+
+const (
+	// Release has been grabbed and sent to the download client.
+	ARR_DOWNLOAD_GRABBED ArrDownloadState = "GRABBED"
+	// A release (movie or episode) has been downloaded and imported.
+	ARR_DOWNLOAD_IMPORTED ArrDownloadState = "IMPORTED"
+)
+
+type ArrWebhookResponse struct {
+	// Path to the webhook, to be appended to the Watcharr url
+	// when configuring the webhook in sonarr/radarr.
+	// Empty when the server has no webhook secret yet.
+	Path string `json:"path"`
+}
+
+// Check `secret` matches the servers secret. Servers without one never match.
+func arrWebhookSecretValid(serverSecret string, secret string) bool {
+	if serverSecret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(serverSecret), []byte(secret)) == 1
+}
+
+func arrWebhookPath(t arr.ArrType, serverName string, secret string) string {
+	ts := "son"
+	if t == arr.RADARR {
+		ts = "rad"
+	}
+	return "/api/arr/webhook/" + ts + "/" + url.PathEscape(serverName) + "/" + url.PathEscape(secret)
+}
+
+// Get webhook path for sonarr server.
+// Path is empty if a secret hasn't been generated yet.
+func getSonarrWebhook(name string) (ArrWebhookResponse, error) {
+	server, err := getSonarr(name)
+	if err != nil {
+		return ArrWebhookResponse{}, errors.New("server not found")
+	}
+	if server.WebhookSecret == "" {
+		return ArrWebhookResponse{}, nil
+	}
+	return ArrWebhookResponse{Path: arrWebhookPath(arr.SONARR, name, server.WebhookSecret)}, nil
+}
+
+// Generate a new webhook secret for sonarr server, the old webhook path stops working.
+func regenerateSonarrWebhook(name string) (ArrWebhookResponse, error) {
+	for i, v := range Config.SONARR {
+		if v.Name == name {
+			secret, err := generateUrlSafeString(32)
+			if err != nil {
+				slog.Error("regenerateSonarrWebhook: Failed to generate secret", "error", err)
+				return ArrWebhookResponse{}, errors.New("failed to generate webhook secret")
+			}
+			Config.SONARR[i].WebhookSecret = secret
+			if err := writeConfig(); err != nil {
+				slog.Error("regenerateSonarrWebhook: Failed to write config", "error", err)
+				return ArrWebhookResponse{}, errors.New("failed to save webhook secret")
+			}
+			return ArrWebhookResponse{Path: arrWebhookPath(arr.SONARR, name, secret)}, nil
+		}
+	}
+	return ArrWebhookResponse{}, errors.New("server not found")
+}
+
+// Get webhook path for radarr server.
+// Path is empty if a secret hasn't been generated yet.
+func getRadarrWebhook(name string) (ArrWebhookResponse, error) {
+	server, err := getRadarr(name)
+	if err != nil {
+		return ArrWebhookResponse{}, errors.New("server not found")
+	}
+	if server.WebhookSecret == "" {
+		return ArrWebhookResponse{}, nil
+	}
+	return ArrWebhookResponse{Path: arrWebhookPath(arr.RADARR, name, server.WebhookSecret)}, nil
+}
+
+// Generate a new webhook secret for radarr server, the old webhook path stops working.
+func regenerateRadarrWebhook(name string) (ArrWebhookResponse, error) {
+	for i, v := range Config.RADARR {
+		if v.Name == name {
+			secret, err := generateUrlSafeString(32)
+			if err != nil {
+				slog.Error("regenerateRadarrWebhook: Failed to generate secret", "error", err)
+				return ArrWebhookResponse{}, errors.New("failed to generate webhook secret")
+			}
+			Config.RADARR[i].WebhookSecret = secret
+			if err := writeConfig(); err != nil {
+				slog.Error("regenerateRadarrWebhook: Failed to write config", "error", err)
+				return ArrWebhookResponse{}, errors.New("failed to save webhook secret")
+			}
+			return ArrWebhookResponse{Path: arrWebhookPath(arr.RADARR, name, secret)}, nil
+		}
+	}
+	return ArrWebhookResponse{}, errors.New("server not found")
+}
+
+// Handle a webhook sent from a sonarr/radarr server.
+// Events are matched to our requests by server name and arr id,
+// events for content that wasn't requested through us are ignored.
+func processArrWebhook(db *gorm.DB, t arr.ArrType, serverName string, secret string, p arr.WebhookPayload) error {
+	var (
+		contentType  ContentType
+		serverSecret string
+		a            *arr.Arr
+	)
+	if t == arr.RADARR {
+		server, err := getRadarr(serverName)
+		if err != nil {
+			return errors.New("invalid webhook")
+		}
+		contentType = MOVIE
+		serverSecret = server.WebhookSecret
+		a = arr.New(arr.RADARR, &server.Host, &server.Key)
+	} else {
+		server, err := getSonarr(serverName)
+		if err != nil {
+			return errors.New("invalid webhook")
+		}
+		contentType = SHOW
+		serverSecret = server.WebhookSecret
+		a = arr.New(arr.SONARR, &server.Host, &server.Key)
+	}
+	if !arrWebhookSecretValid(serverSecret, secret) {
+		slog.Warn("processArrWebhook: Webhook received with an invalid secret", "type", t, "server_name", serverName)
+		return errors.New("invalid webhook")
+	}
+	slog.Debug("processArrWebhook: Webhook received", "type", t, "server_name", serverName, "event_type", p.EventType, "arr_id", p.ArrID())
+	if p.EventType == arr.WEBHOOK_TEST || p.ArrID() == 0 {
+		return nil
+	}
+	// Webhooks don't include download progress, so refresh the servers queue
+	// now instead of waiting for the refresh task, so queue details are up to date.
+	if p.EventType == arr.WEBHOOK_GRAB || p.EventType == arr.WEBHOOK_DOWNLOAD {
+		go a.RunCommand("RefreshMonitoredDownloads")
+	}
+	// Series can have multiple requests (for more seasons), handle all of them.
+	var reqs []ArrRequest
+	resp := db.
+		Joins("JOIN contents ON contents.id = arr_requests.content_id AND contents.type = ?", contentType).
+		Preload("Content").
+		Where("arr_requests.server_name = ? AND arr_requests.arr_id = ?", serverName, p.ArrID()).
+		Find(&reqs)
+	if resp.Error != nil {
+		slog.Error("processArrWebhook: Failed to search for requests in db", "error", resp.Error)
+		return errors.New("failed to find request")
+	}
+	if len(reqs) == 0 {
+		slog.Debug("processArrWebhook: No request found for this content, ignoring.", "arr_id", p.ArrID())
+		return nil
+	}
+	for _, req := range reqs {
+		if err := processArrWebhookForRequest(db, t, a, req, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update one of our requests from a webhook event.
+func processArrWebhookForRequest(db *gorm.DB, t arr.ArrType, a *arr.Arr, req ArrRequest, p arr.WebhookPayload) error {
+	// Only requests that have been sent to the server should be updated.
+	if req.Status == ARR_REQUEST_PENDING || req.Status == ARR_REQUEST_DENIED {
+		slog.Debug("processArrWebhook: Request not sent to server, ignoring.", "request_id", req.ID, "status", req.Status)
+		return nil
+	}
+	switch p.EventType {
+	case arr.WEBHOOK_GRAB:
+		if res := db.Model(&ArrRequest{}).Where("id = ?", req.ID).Update("download_state", ARR_DOWNLOAD_GRABBED); res.Error != nil {
+			slog.Error("processArrWebhook: Failed to update request download state", "request_id", req.ID, "error", res.Error)
+			return errors.New("failed to update request")
+		}
+	case arr.WEBHOOK_DOWNLOAD:
+		if res := db.Model(&ArrRequest{}).Where("id = ?", req.ID).Update("download_state", ARR_DOWNLOAD_IMPORTED); res.Error != nil {
+			slog.Error("processArrWebhook: Failed to update request download state", "request_id", req.ID, "error", res.Error)
+			return errors.New("failed to update request")
+		}
+		if req.Status == ARR_REQUEST_AVAILABLE {
+			return nil
+		}
+		// Sonarr sends a download event per episode, so check the
+		// whole series has been downloaded before it's available.
+		if t == arr.SONARR {
+			content, _, err := a.GetContent(req.ArrID)
+			if err != nil {
+				slog.Error("processArrWebhook: Failed to get content from server", "request_id", req.ID, "error", err)
+				return errors.New("failed to get content from server")
+			}
+			if !content.IsDownloaded() {
+				return nil
+			}
+		}
+		if res := db.Model(&ArrRequest{}).Where("id = ?", req.ID).Update("status", ARR_REQUEST_AVAILABLE); res.Error != nil {
+			slog.Error("processArrWebhook: Failed to update request status", "request_id", req.ID, "error", res.Error)
+			return errors.New("failed to update request")
+		}
+		addArrRequestEvent(db, req.ID, nil, ARR_REQUEST_AVAILABLE, "")
+		notifyArrRequestStatusChanged(db, req, ARR_REQUEST_AVAILABLE)
+	case arr.WEBHOOK_MOVIE_DELETE, arr.WEBHOOK_SERIES_DELETE:
+		slog.Info("processArrWebhook: Content was removed from server.. removing request.", "request_id", req.ID)
+		return deleteArrRequest(db, req.ID)
+	default:
+		slog.Debug("processArrWebhook: Unhandled event type, ignoring.", "event_type", p.EventType)
+	}
+	return nil
+}
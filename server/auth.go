@@ -65,6 +65,11 @@ type User struct {
 	Tags []Tag `json:"-"`
 	// Users permissions
 	Permissions int `gorm:"default:1" json:"-"`
+	// Overrides for the servers arr request quota (nil to use server default, 0 for unlimited).
+	RequestQuotaMovies  *int `json:"-"`
+	RequestQuotaSeasons *int `json:"-"`
+	// Token for users calendar feed (nil if not enabled).
+	CalendarToken *string `json:"-" gorm:"uniqueIndex"`
 	// All user settings cols, in another struct for reusability
 	UserSettings
 }
@@ -93,6 +98,9 @@ type UserSettings struct {
 	IncludePreviouslyWatched *bool `gorm:"default:false" json:"includePreviouslyWatched"`
 	// User's country to get correct content streaming providers.
 	Country *string `gorm:"default:'US'" json:"country"`
+	// User's language for content metadata (eg: de-DE).
+	// When unset, the server default language is used.
+	Language *string `json:"language" binding:"omitempty,bcp47_language_tag"`
 	// Does the user want show, season and episode automations enabled.
 	AutomateShowStatuses *bool `gorm:"default:true" json:"automateShowStatuses"`
 	// Rating system user wants to use (frontend only).
@@ -393,7 +401,6 @@ func loginJellyfin(user *User, db *gorm.DB) (AuthResponse, error) {
 		return AuthResponse{}, errors.New("failed to marshal json")
 	}
 	// Run auth request
-	client := &http.Client{}
 	req, err := http.NewRequest("POST", base.String(), bytes.NewBuffer(usrJSON))
 	if err != nil {
 		slog.Error("Creating request to jellyfin for auth failed", "error", err)
@@ -401,7 +408,7 @@ func loginJellyfin(user *User, db *gorm.DB) (AuthResponse, error) {
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-Emby-Authorization", "MediaBrowser Client=\"Watcharr\", Device=\"HTTP\", DeviceId=\"WatcharrFor"+user.Username+"\", Version=\"10.8.0\"")
-	res, err := client.Do(req)
+	res, err := jellyfinHttp.Do(req)
 	if err != nil {
 		slog.Error("making request to jellyfin for auth failed", "error", err)
 		return AuthResponse{}, errors.New("request failed")
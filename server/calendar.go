@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalendarFeedResponse struct {
+	// Token to put in the feed url (/api/calendar/{token}.ics).
+	Token string `json:"token"`
+}
+
+type CalendarEvent struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// How far back and forward events are included in a feed.
+const (
+	calendarFeedPast   = 30 * 24 * time.Hour
+	calendarFeedFuture = 365 * 24 * time.Hour
+)
+
+// TMDB release types we add to calendars.
+var calendarReleaseTypes = map[int]string{
+	3: "Theatrical Release",
+	4: "Digital Release",
+}
+
+// Get users calendar token, creating one if they don't have one.
+func getCalendarToken(db *gorm.DB, userId uint) (CalendarFeedResponse, error) {
+	var token *string
+	if res := db.Model(&User{}).Select("calendar_token").Where("id = ?", userId).Scan(&token); res.Error != nil {
+		slog.Error("getCalendarToken: Failed to get token", "user_id", userId, "error", res.Error)
+		return CalendarFeedResponse{}, errors.New("failed to get calendar token")
+	}
+	if token != nil && *token != "" {
+		return CalendarFeedResponse{Token: *token}, nil
+	}
+	return regenerateCalendarToken(db, userId)
+}
+
+// Create a new calendar token for user, the old feed url will stop working.
+func regenerateCalendarToken(db *gorm.DB, userId uint) (CalendarFeedResponse, error) {
+	token, err := generateUrlSafeString(32)
+	if err != nil {
+		slog.Error("regenerateCalendarToken: Failed to generate token", "error", err)
+		return CalendarFeedResponse{}, errors.New("failed to generate calendar token")
+	}
+	if res := db.Model(&User{}).Where("id = ?", userId).Update("calendar_token", token); res.Error != nil {
+		slog.Error("regenerateCalendarToken: Failed to save token", "user_id", userId, "error", res.Error)
+		return CalendarFeedResponse{}, errors.New("failed to save calendar token")
+	}
+	return CalendarFeedResponse{Token: token}, nil
+}
+
+// Remove users calendar token, disabling their feed.
+func deleteCalendarToken(db *gorm.DB, userId uint) error {
+	if res := db.Model(&User{}).Where("id = ?", userId).Update("calendar_token", nil); res.Error != nil {
+		slog.Error("deleteCalendarToken: Failed to remove token", "user_id", userId, "error", res.Error)
+		return errors.New("failed to remove calendar token")
+	}
+	return nil
+}
+
+// Get calendar feed (ics) for the user the token belongs to.
+func getCalendarFeed(db *gorm.DB, token string) (string, error) {
+	if token == "" {
+		return "", errors.New("invalid calendar token")
+	}
+	user := new(User)
+	if res := db.Where("calendar_token = ?", token).Take(&user); res.Error != nil {
+		if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			slog.Error("getCalendarFeed: Failed to get user", "error", res.Error)
+		}
+		return "", errors.New("invalid calendar token")
+	}
+	country := "US"
+	if user.Country != nil && *user.Country != "" {
+		country = *user.Country
+	}
+	from := time.Now().Add(-calendarFeedPast)
+	to := time.Now().Add(calendarFeedFuture)
+	events, err := getCalendarEpisodeEvents(db, user.ID, from, to)
+	if err != nil {
+		return "", err
+	}
+	movieEvents, err := getCalendarMovieEvents(db, user.ID, country, from, to)
+	if err != nil {
+		return "", err
+	}
+	return buildICS(append(events, movieEvents...)), nil
+}
+
+// Upcoming (and recent) episodes for shows on users list (that they haven't dropped).
+func getCalendarEpisodeEvents(db *gorm.DB, userId uint, from time.Time, to time.Time) ([]CalendarEvent, error) {
+	rows := []struct {
+		Episode
+		Title string
+	}{}
+	res := db.Model(&Episode{}).
+		Select("episodes.*, contents.title").
+		Joins("JOIN contents ON contents.id = episodes.content_id").
+		Joins("JOIN watcheds ON watcheds.content_id = contents.id AND watcheds.deleted_at IS NULL").
+		Where("watcheds.user_id = ? AND watcheds.status != ? AND episodes.air_date >= ? AND episodes.air_date < ?", userId, DROPPED, from, to).
+		Order("episodes.air_date").
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("getCalendarEpisodeEvents: Failed to get episodes", "user_id", userId, "error", res.Error)
+		return []CalendarEvent{}, errors.New("failed to get episodes")
+	}
+	events := []CalendarEvent{}
+	for _, r := range rows {
+		summary := fmt.Sprintf("%s S%02dE%02d", r.Title, r.SeasonNumber, r.EpisodeNumber)
+		if r.Name != "" {
+			summary += " - " + r.Name
+		}
+		events = append(events, CalendarEvent{
+			UID:         fmt.Sprintf("episode-%d-%d-%d@watcharr", r.ContentID, r.SeasonNumber, r.EpisodeNumber),
+			Date:        *r.AirDate,
+			Summary:     summary,
+			Description: r.Overview,
+		})
+	}
+	return events, nil
+}
+
+// Release dates for movies the user is planning to watch.
+func getCalendarMovieEvents(db *gorm.DB, userId uint, country string, from time.Time, to time.Time) ([]CalendarEvent, error) {
+	movies := []Content{}
+	// Digital releases usually come months after the initial release, so
+	// include movies released in the past year that could still have them.
+	res := db.Model(&Content{}).
+		Joins("JOIN watcheds ON watcheds.content_id = contents.id AND watcheds.deleted_at IS NULL").
+		Where("watcheds.user_id = ? AND watcheds.status = ? AND contents.type = ?", userId, PLANNED, MOVIE).
+		Where("contents.release_date IS NULL OR contents.release_date >= ?", time.Now().AddDate(-1, 0, 0)).
+		Find(&movies)
+	if res.Error != nil {
+		slog.Error("getCalendarMovieEvents: Failed to get planned movies", "user_id", userId, "error", res.Error)
+		return []CalendarEvent{}, errors.New("failed to get planned movies")
+	}
+	releaseDates, err := getMovieReleaseDates(db, movies)
+	if err != nil {
+		slog.Error("getCalendarMovieEvents: Failed to get release dates", "user_id", userId, "error", err)
+		return []CalendarEvent{}, errors.New("failed to get release dates")
+	}
+	events := []CalendarEvent{}
+	for _, m := range movies {
+		for t, name := range calendarReleaseTypes {
+			date, ok := getMovieReleaseDate(releaseDates[m.ID], country, t)
+			if !ok || date.Before(from) || !date.Before(to) {
+				continue
+			}
+			events = append(events, CalendarEvent{
+				UID:         fmt.Sprintf("movie-%d-%d@watcharr", m.ID, t),
+				Date:        date,
+				Summary:     m.Title + " (" + name + ")",
+				Description: m.Overview,
+			})
+		}
+	}
+	return events, nil
+}
+
+// Get our stored release dates for movies, by content id.
+func getMovieReleaseDates(db *gorm.DB, movies []Content) (map[int][]ContentReleaseDate, error) {
+	ids := make([]int, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	dates := []ContentReleaseDate{}
+	if len(ids) > 0 {
+		if res := db.Where("content_id IN ?", ids).Find(&dates); res.Error != nil {
+			return nil, res.Error
+		}
+	}
+	byContent := map[int][]ContentReleaseDate{}
+	for _, d := range dates {
+		byContent[d.ContentID] = append(byContent[d.ContentID], d)
+	}
+	return byContent, nil
+}
+
+// Get earliest release date of type in country, falling back to US.
+func getMovieReleaseDate(dates []ContentReleaseDate, country string, releaseType int) (time.Time, bool) {
+	for _, c := range []string{country, "US"} {
+		var earliest time.Time
+		for _, d := range dates {
+			if !strings.EqualFold(d.Country, c) || d.Type != releaseType {
+				continue
+			}
+			if earliest.IsZero() || d.ReleaseDate.Before(earliest) {
+				earliest = d.ReleaseDate
+			}
+		}
+		if !earliest.IsZero() {
+			return earliest, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Build iCalendar (RFC 5545) file from events, as all day events.
+func buildICS(events []CalendarEvent) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Watcharr//Calendar//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:Watcharr")
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+e.UID)
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART;VALUE=DATE:"+e.Date.Format("20060102"))
+		writeICSLine(&b, "DTEND;VALUE=DATE:"+e.Date.AddDate(0, 0, 1).Format("20060102"))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(e.Summary))
+		if e.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(e.Description))
+		}
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// Write content line, folding lines longer than 75 octets (without splitting characters).
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Folded lines start with a space, which counts towards the limit.
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
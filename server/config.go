@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/sbondCo/Watcharr/game"
+	"golang.org/x/text/language"
 	"gorm.io/gorm"
 )
 
@@ -30,6 +31,10 @@ type ServerConfig struct {
 	// region to get correct content streaming providers.
 	DEFAULT_COUNTRY string `json:",omitempty"`
 
+	// Default language (eg: de-DE) for content metadata, used for users
+	// that haven't chosen their own. Defaults to en-US when unset.
+	DEFAULT_LANGUAGE string `json:",omitempty"`
+
 	// Optional: Point to your Jellyfin install
 	// to enable it as an auth provider.
 	JELLYFIN_HOST string `json:",omitempty"`
@@ -58,6 +63,27 @@ type ServerConfig struct {
 	RADARR []RadarrSettings `json:",omitempty"`
 	TWITCH game.IGDB        `json:",omitempty"`
 
+	// Optional: Limit how much content each user can request from
+	// sonarr/radarr in a rolling window of ARR_REQUEST_QUOTA_DAYS days.
+	// Zero (default) means unlimited. Can be overridden per user.
+	ARR_REQUEST_QUOTA_MOVIES  int `json:",omitempty"`
+	ARR_REQUEST_QUOTA_SEASONS int `json:",omitempty"`
+	// Optional: Number of days the request quota applies to (defaults to 7).
+	ARR_REQUEST_QUOTA_DAYS int `json:",omitempty"`
+
+	// Optional: Rules for routing requests to sonarr/radarr servers.
+	ARR_ROUTING_RULES []ArrRoutingRule `json:",omitempty"`
+
+	// Optional: Require admins to give a reason when denying a request.
+	ARR_REQUEST_DENY_REASON_REQUIRED bool `json:",omitempty"`
+
+	// Optional: Max size (in MB) of the TMDB response cache (defaults to 200).
+	TMDB_CACHE_MAX_MB int `json:",omitempty"`
+
+	// Optional: Days removed watched items are kept in the trash
+	// (so they can be restored) before being purged (defaults to 30).
+	TRASH_RETENTION_DAYS int `json:",omitempty"`
+
 	// Optional: Schedule for tasks.
 	TASK_SCHEDULE map[string]int `json:",omitempty"`
 
@@ -76,16 +102,23 @@ type ServerConfig struct {
 // not editable on frontend, so not needed).
 func (c *ServerConfig) GetSafe() ServerConfig {
 	return ServerConfig{
-		SIGNUP_ENABLED:  c.SIGNUP_ENABLED,
-		DEFAULT_COUNTRY: c.DEFAULT_COUNTRY,
-		JELLYFIN_HOST:   c.JELLYFIN_HOST,
-		USE_EMBY:        c.USE_EMBY,
-		TMDB_KEY:        c.TMDB_KEY,
-		PLEX_HOST:       c.PLEX_HOST,
-		PLEX_MACHINE_ID: c.PLEX_MACHINE_ID,
-		DEBUG:           c.DEBUG,
-		SONARR:          c.SONARR, // Dont act safe, this contains sonarr api key, needed for config
-		RADARR:          c.RADARR, // Dont act safe, this contains radarr api key, needed for config
+		SIGNUP_ENABLED:                   c.SIGNUP_ENABLED,
+		DEFAULT_COUNTRY:                  c.DEFAULT_COUNTRY,
+		DEFAULT_LANGUAGE:                 c.DEFAULT_LANGUAGE,
+		JELLYFIN_HOST:                    c.JELLYFIN_HOST,
+		USE_EMBY:                         c.USE_EMBY,
+		TMDB_KEY:                         c.TMDB_KEY,
+		PLEX_HOST:                        c.PLEX_HOST,
+		PLEX_MACHINE_ID:                  c.PLEX_MACHINE_ID,
+		DEBUG:                            c.DEBUG,
+		ARR_REQUEST_QUOTA_MOVIES:         c.ARR_REQUEST_QUOTA_MOVIES,
+		ARR_REQUEST_QUOTA_SEASONS:        c.ARR_REQUEST_QUOTA_SEASONS,
+		ARR_REQUEST_QUOTA_DAYS:           c.ARR_REQUEST_QUOTA_DAYS,
+		ARR_REQUEST_DENY_REASON_REQUIRED: c.ARR_REQUEST_DENY_REASON_REQUIRED,
+		TMDB_CACHE_MAX_MB:                c.TMDB_CACHE_MAX_MB,
+		TRASH_RETENTION_DAYS:             c.TRASH_RETENTION_DAYS,
+		SONARR:                           c.SONARR, // Dont act safe, this contains sonarr api key, needed for config
+		RADARR:                           c.RADARR, // Dont act safe, this contains radarr api key, needed for config
 		TWITCH: game.IGDB{
 			ClientID:     c.TWITCH.ClientID,
 			ClientSecret: c.TWITCH.ClientSecret,
@@ -168,6 +201,40 @@ func updateConfig(k string, v any) error {
 		setLoggingLevel()
 	} else if k == "DEFAULT_COUNTRY" {
 		Config.DEFAULT_COUNTRY = v.(string)
+	} else if k == "DEFAULT_LANGUAGE" {
+		lang, ok := v.(string)
+		if !ok {
+			return errors.New("invalid value, must be a string")
+		}
+		if lang != "" {
+			if _, err := language.Parse(lang); err != nil {
+				return errors.New("invalid language tag")
+			}
+		}
+		Config.DEFAULT_LANGUAGE = lang
+	} else if k == "ARR_REQUEST_DENY_REASON_REQUIRED" {
+		b, ok := v.(bool)
+		if !ok {
+			return errors.New("invalid value, must be a boolean")
+		}
+		Config.ARR_REQUEST_DENY_REASON_REQUIRED = b
+	} else if k == "ARR_REQUEST_QUOTA_MOVIES" || k == "ARR_REQUEST_QUOTA_SEASONS" || k == "ARR_REQUEST_QUOTA_DAYS" || k == "TMDB_CACHE_MAX_MB" || k == "TRASH_RETENTION_DAYS" {
+		// Numbers from json requests are always float64.
+		f, ok := v.(float64)
+		if !ok || f < 0 {
+			return errors.New("invalid value, must be a positive number")
+		}
+		if k == "ARR_REQUEST_QUOTA_MOVIES" {
+			Config.ARR_REQUEST_QUOTA_MOVIES = int(f)
+		} else if k == "ARR_REQUEST_QUOTA_SEASONS" {
+			Config.ARR_REQUEST_QUOTA_SEASONS = int(f)
+		} else if k == "TMDB_CACHE_MAX_MB" {
+			Config.TMDB_CACHE_MAX_MB = int(f)
+		} else if k == "TRASH_RETENTION_DAYS" {
+			Config.TRASH_RETENTION_DAYS = int(f)
+		} else {
+			Config.ARR_REQUEST_QUOTA_DAYS = int(f)
+		}
 	} else {
 		return errors.New("invalid setting")
 	}
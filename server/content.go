@@ -22,6 +22,19 @@ const (
 
 var ContentStore = persistence.NewInMemoryStore(time.Hour * 24)
 
+// Appended to details requests when caching content, so
+// we can also store its credits and keywords.
+const contentMetaAppend = "credits,keywords"
+
+// Get what to append to details requests when caching content of type,
+// movies also include release dates (used for the calendar and notifications).
+func getContentMetaAppend(contentType ContentType) string {
+	if contentType == MOVIE {
+		return contentMetaAppend + ",release_dates"
+	}
+	return contentMetaAppend
+}
+
 // For storing cached content, so we can serve the basic local data for watched list to work
 type Content struct {
 	ID               int         `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -41,6 +54,8 @@ type Content struct {
 	Runtime          uint32      `json:"runtime"`
 	NumberOfEpisodes uint32      `json:"numberOfEpisodes"`
 	NumberOfSeasons  uint32      `json:"numberOfSeasons"`
+	// When this row was last updated with details from TMDB.
+	UpdatedAt time.Time `json:"-"`
 }
 
 // onlyUpdate - If we should only update existing row if exists, or false to create/update if not exist.
@@ -77,6 +92,7 @@ func saveContent(db *gorm.DB, c *Content, onlyUpdate bool) error {
 				"runtime",
 				"number_of_episodes",
 				"number_of_seasons",
+				"updated_at",
 			}),
 		}).Create(&c)
 		if res.Error != nil {
@@ -134,6 +150,14 @@ func cacheContentTv(db *gorm.DB, content TMDBShowDetails, onlyUpdate bool) (Cont
 		slog.Error("cacheContentTv: Failed to save content!", "error", err)
 		return Content{}, errors.New("failed to save content")
 	}
+	var keywords []TMDBNamedItem
+	if content.Keywords != nil {
+		keywords = append([]TMDBNamedItem{}, content.Keywords.Results...)
+	}
+	saveContentMeta(db, SHOW, content.ID, content.Genres, keywords, content.Credits)
+	if content.NextEpisodeToAir != nil {
+		cacheNextEpisode(db, content.ID, *content.NextEpisodeToAir)
+	}
 
 	return c, nil
 }
@@ -171,6 +195,14 @@ func cacheContentMovie(db *gorm.DB, content TMDBMovieDetails, onlyUpdate bool) (
 		slog.Error("cacheContentMovie: Failed to save content!", "error", err)
 		return Content{}, errors.New("failed to save content")
 	}
+	var keywords []TMDBNamedItem
+	if content.Keywords != nil {
+		keywords = append([]TMDBNamedItem{}, content.Keywords.Keywords...)
+	}
+	saveContentMeta(db, MOVIE, content.ID, content.Genres, keywords, content.Credits)
+	if content.ReleaseDates != nil {
+		cacheContentReleaseDates(db, content.ID, *content.ReleaseDates)
+	}
 
 	return c, nil
 }
@@ -184,7 +216,7 @@ func getOrCacheContent(db *gorm.DB, contentType ContentType, tmdbId int) (Conten
 	if content == (Content{}) {
 		slog.Debug("Content not in db, fetching...", "type", contentType, "tmdbId", tmdbId)
 
-		resp, err := tmdbAPIRequest("/"+string(contentType)+"/"+strconv.Itoa(tmdbId), map[string]string{})
+		resp, err := tmdbAPIRequest("/"+string(contentType)+"/"+strconv.Itoa(tmdbId), map[string]string{"append_to_response": getContentMetaAppend(contentType)})
 		if err != nil {
 			slog.Error("getOrCacheContent: content tmdb api request failed", "error", err)
 			return Content{}, errors.New("failed to find requested media")
@@ -215,6 +247,10 @@ func getOrCacheContent(db *gorm.DB, contentType ContentType, tmdbId int) (Conten
 				return Content{}, errors.New("failed to cache content")
 			}
 		}
+		go func() {
+			cacheContentTranslations(db, content)
+			cacheShowSeasons(db, content)
+		}()
 	}
 	return content, nil
 }
@@ -243,12 +279,12 @@ func transformProviders(c *interface{}, country string) {
 	}
 }
 
-func searchContent(query string, pageNum int) (TMDBSearchMultiResponse, error) {
+func searchContent(query string, pageNum int, lang string) (TMDBSearchMultiResponse, error) {
 	resp := new(TMDBSearchMultiResponse)
 	if pageNum == 0 {
 		pageNum = 1
 	}
-	err := tmdbRequest("/search/multi", map[string]string{"query": query, "page": strconv.Itoa(pageNum)}, &resp)
+	err := tmdbRequest("/search/multi", map[string]string{"query": query, "page": strconv.Itoa(pageNum), "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete multi search request!", "error", err.Error())
 		return TMDBSearchMultiResponse{}, errors.New("failed to complete multi search request")
@@ -256,12 +292,12 @@ func searchContent(query string, pageNum int) (TMDBSearchMultiResponse, error) {
 	return *resp, nil
 }
 
-func searchMovies(query string, pageNum int) (TMDBSearchMoviesResponse, error) {
+func searchMovies(query string, pageNum int, lang string) (TMDBSearchMoviesResponse, error) {
 	resp := new(TMDBSearchMoviesResponse)
 	if pageNum == 0 {
 		pageNum = 1
 	}
-	err := tmdbRequest("/search/movie", map[string]string{"query": query, "page": strconv.Itoa(pageNum)}, &resp)
+	err := tmdbRequest("/search/movie", map[string]string{"query": query, "page": strconv.Itoa(pageNum), "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete movie search request!", "error", err.Error())
 		return TMDBSearchMoviesResponse{}, errors.New("failed to complete movie search request")
@@ -272,12 +308,12 @@ func searchMovies(query string, pageNum int) (TMDBSearchMoviesResponse, error) {
 	return *resp, nil
 }
 
-func searchTv(query string, pageNum int) (TMDBSearchShowsResponse, error) {
+func searchTv(query string, pageNum int, lang string) (TMDBSearchShowsResponse, error) {
 	resp := new(TMDBSearchShowsResponse)
 	if pageNum == 0 {
 		pageNum = 1
 	}
-	err := tmdbRequest("/search/tv", map[string]string{"query": query, "page": strconv.Itoa(pageNum)}, &resp)
+	err := tmdbRequest("/search/tv", map[string]string{"query": query, "page": strconv.Itoa(pageNum), "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete tv search request!", "error", err.Error())
 		return TMDBSearchShowsResponse{}, errors.New("failed to complete tv search request")
@@ -288,12 +324,12 @@ func searchTv(query string, pageNum int) (TMDBSearchShowsResponse, error) {
 	return *resp, nil
 }
 
-func searchPeople(query string, pageNum int) (TMDBSearchPeopleResponse, error) {
+func searchPeople(query string, pageNum int, lang string) (TMDBSearchPeopleResponse, error) {
 	resp := new(TMDBSearchPeopleResponse)
 	if pageNum == 0 {
 		pageNum = 1
 	}
-	err := tmdbRequest("/search/person", map[string]string{"query": query, "page": strconv.Itoa(pageNum)}, &resp)
+	err := tmdbRequest("/search/person", map[string]string{"query": query, "page": strconv.Itoa(pageNum), "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete people search request!", "error", err.Error())
 		return TMDBSearchPeopleResponse{}, errors.New("failed to complete people search request")
@@ -312,17 +348,25 @@ func movieDetails(db *gorm.DB, id string, country string, rParams map[string]str
 		return TMDBMovieDetails{}, errors.New("failed to complete movie details request")
 	}
 	transformProviders(&resp.WatchProviders, country)
-	go cacheContentMovie(db, *resp, true)
+	if lang := rParams["language"]; isDefaultContentLanguage(lang) {
+		go cacheContentMovie(db, *resp, true)
+	} else {
+		// Our cached content stays in the default language, localized details are kept separately.
+		go saveContentTranslation(db, MOVIE, resp.ID, lang, resp.Title, resp.Overview)
+	}
 	return *resp, nil
 }
 
-func movieCredits(id string) (TMDBContentCredits, error) {
+func movieCredits(db *gorm.DB, id string, lang string) (TMDBContentCredits, error) {
 	resp := new(TMDBContentCredits)
-	err := tmdbRequest("/movie/"+id+"/credits", map[string]string{}, &resp)
+	err := tmdbRequest("/movie/"+id+"/credits", map[string]string{"language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete movie cast request!", "error", err.Error())
 		return TMDBContentCredits{}, errors.New("failed to complete movie cast request")
 	}
+	if isDefaultContentLanguage(lang) {
+		go cacheContentCredits(db, MOVIE, *resp)
+	}
 	return *resp, nil
 }
 
@@ -334,23 +378,33 @@ func tvDetails(db *gorm.DB, id string, country string, rParams map[string]string
 		return TMDBShowDetails{}, errors.New("failed to complete tv details request")
 	}
 	transformProviders(&resp.WatchProviders, country)
-	go cacheContentTv(db, *resp, true)
+	if lang := rParams["language"]; isDefaultContentLanguage(lang) {
+		go cacheContentTv(db, *resp, true)
+	} else {
+		go saveContentTranslation(db, SHOW, resp.ID, lang, resp.Name, resp.Overview)
+	}
 	return *resp, nil
 }
 
-func tvCredits(id string) (TMDBContentCredits, error) {
+func tvCredits(db *gorm.DB, id string, lang string) (TMDBContentCredits, error) {
 	resp := new(TMDBContentCredits)
-	err := tmdbRequest("/tv/"+id+"/credits", map[string]string{}, &resp)
+	err := tmdbRequest("/tv/"+id+"/credits", map[string]string{"language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete tv cast request!", "error", err.Error())
 		return TMDBContentCredits{}, errors.New("failed to complete tv cast request")
 	}
+	if isDefaultContentLanguage(lang) {
+		go cacheContentCredits(db, SHOW, *resp)
+	}
 	return *resp, nil
 }
 
 // This method is manually cached, so it can be easily used in other places (on the server) with cache benefits
-func seasonDetails(tvId string, seasonNumber string) (TMDBSeasonDetails, error) {
-	var cacheKey = "contentstore-seasondetails-" + tvId + "-" + seasonNumber
+func seasonDetails(db *gorm.DB, tvId string, seasonNumber string, lang string) (TMDBSeasonDetails, error) {
+	if lang == "" {
+		lang = tmdbDefaultLanguage
+	}
+	var cacheKey = "contentstore-seasondetails-" + tvId + "-" + seasonNumber + "-" + lang
 	resp := new(TMDBSeasonDetails)
 	if err := ContentStore.Get(cacheKey, &resp); err != nil {
 		if err != persistence.ErrCacheMiss {
@@ -360,20 +414,33 @@ func seasonDetails(tvId string, seasonNumber string) (TMDBSeasonDetails, error)
 		slog.Debug("seasonDetails: Returning cache.")
 		return *resp, nil
 	}
-	err := tmdbRequest("/tv/"+tvId+"/season/"+seasonNumber, map[string]string{}, &resp)
+	return fetchSeasonDetails(db, tvId, seasonNumber, lang)
+}
+
+// Get season details from TMDB, skipping our in memory cache (but updating it),
+// so its seasons and episodes are always saved to our db.
+func fetchSeasonDetails(db *gorm.DB, tvId string, seasonNumber string, lang string) (TMDBSeasonDetails, error) {
+	var cacheKey = "contentstore-seasondetails-" + tvId + "-" + seasonNumber + "-" + lang
+	resp := new(TMDBSeasonDetails)
+	err := tmdbRequest("/tv/"+tvId+"/season/"+seasonNumber, map[string]string{"language": lang}, &resp)
 	if err != nil {
-		slog.Error("seasonDetails: Failed to complete season details request!", "error", err.Error())
+		slog.Error("fetchSeasonDetails: Failed to complete season details request!", "error", err.Error())
 		return TMDBSeasonDetails{}, errors.New("failed to complete season details request")
 	}
 	if err := ContentStore.Set(cacheKey, resp, time.Hour*24); err != nil {
-		slog.Error("seasonDetails: Failed to set cache!", "error", err)
+		slog.Error("fetchSeasonDetails: Failed to set cache!", "error", err)
+	}
+	if isDefaultContentLanguage(lang) {
+		if id, err := strconv.Atoi(tvId); err == nil {
+			cacheSeasonDetails(db, id, *resp)
+		}
 	}
 	return *resp, nil
 }
 
-func personDetails(id string) (TMDBPersonDetails, error) {
+func personDetails(id string, lang string) (TMDBPersonDetails, error) {
 	resp := new(TMDBPersonDetails)
-	err := tmdbRequest("/person/"+id, map[string]string{}, &resp)
+	err := tmdbRequest("/person/"+id, map[string]string{"language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete person details request!", "error", err.Error())
 		return TMDBPersonDetails{}, errors.New("failed to complete person details request")
@@ -381,9 +448,9 @@ func personDetails(id string) (TMDBPersonDetails, error) {
 	return *resp, nil
 }
 
-func personCredits(id string) (TMDBPersonCombinedCredits, error) {
+func personCredits(id string, lang string) (TMDBPersonCombinedCredits, error) {
 	resp := new(TMDBPersonCombinedCredits)
-	err := tmdbRequest("/person/"+id+"/combined_credits", map[string]string{}, &resp)
+	err := tmdbRequest("/person/"+id+"/combined_credits", map[string]string{"language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete person details request!", "error", err.Error())
 		return TMDBPersonCombinedCredits{}, errors.New("failed to complete person details request")
@@ -391,9 +458,9 @@ func personCredits(id string) (TMDBPersonCombinedCredits, error) {
 	return *resp, nil
 }
 
-func discoverMovies() (TMDBDiscoverMovies, error) {
+func discoverMovies(lang string) (TMDBDiscoverMovies, error) {
 	resp := new(TMDBDiscoverMovies)
-	err := tmdbRequest("/discover/movie", map[string]string{"page": "1"}, &resp)
+	err := tmdbRequest("/discover/movie", map[string]string{"page": "1", "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete discover movies request!", "error", err.Error())
 		return TMDBDiscoverMovies{}, errors.New("failed to complete discover movies request")
@@ -401,9 +468,9 @@ func discoverMovies() (TMDBDiscoverMovies, error) {
 	return *resp, nil
 }
 
-func discoverTv() (TMDBDiscoverShows, error) {
+func discoverTv(lang string) (TMDBDiscoverShows, error) {
 	resp := new(TMDBDiscoverShows)
-	err := tmdbRequest("/discover/tv", map[string]string{"page": "1"}, &resp)
+	err := tmdbRequest("/discover/tv", map[string]string{"page": "1", "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete discover tv request!", "error", err.Error())
 		return TMDBDiscoverShows{}, errors.New("failed to complete discover tv request")
@@ -411,9 +478,9 @@ func discoverTv() (TMDBDiscoverShows, error) {
 	return *resp, nil
 }
 
-func allTrending() (TMDBTrendingAll, error) {
+func allTrending(lang string) (TMDBTrendingAll, error) {
 	resp := new(TMDBTrendingAll)
-	err := tmdbRequest("/trending/all/day", map[string]string{}, &resp)
+	err := tmdbRequest("/trending/all/day", map[string]string{"language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete all trending request!", "error", err.Error())
 		return TMDBTrendingAll{}, errors.New("failed to complete all trending request")
@@ -421,9 +488,9 @@ func allTrending() (TMDBTrendingAll, error) {
 	return *resp, nil
 }
 
-func upcomingMovies() (TMDBUpcomingMovies, error) {
+func upcomingMovies(lang string) (TMDBUpcomingMovies, error) {
 	resp := new(TMDBUpcomingMovies)
-	err := tmdbRequest("/movie/upcoming", map[string]string{"page": "1"}, &resp)
+	err := tmdbRequest("/movie/upcoming", map[string]string{"page": "1", "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete upcoming movies request!", "error", err.Error())
 		return TMDBUpcomingMovies{}, errors.New("failed to complete upcoming movies request")
@@ -432,12 +499,12 @@ func upcomingMovies() (TMDBUpcomingMovies, error) {
 }
 
 // Theres no upcoming endpoint for tv ;( - using discover with future dates
-func upcomingTv() (TMDBUpcomingShows, error) {
+func upcomingTv(lang string) (TMDBUpcomingShows, error) {
 	resp := new(TMDBUpcomingShows)
 	dFmt := "2006-01-02"
 	mind := time.Now().Format(dFmt)
 	maxd := time.Now().AddDate(0, 0, 15).Format(dFmt)
-	err := tmdbRequest("/discover/tv", map[string]string{"page": "1", "first_air_date.gte": mind, "first_air_date.lte": maxd, "sort_by": "popularity.desc", "with_type": "2|3"}, &resp)
+	err := tmdbRequest("/discover/tv", map[string]string{"page": "1", "first_air_date.gte": mind, "first_air_date.lte": maxd, "sort_by": "popularity.desc", "with_type": "2|3", "language": lang}, &resp)
 	if err != nil {
 		slog.Error("Failed to complete upcoming tv request!", "error", err.Error())
 		return TMDBUpcomingShows{}, errors.New("failed to complete upcoming tv request")
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cached season details for show content.
+type Season struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UpdatedAt    time.Time  `json:"-"`
+	ContentID    int        `json:"-" gorm:"uniqueIndex:ssn_content_to_num;not null"`
+	SeasonNumber int        `json:"seasonNumber" gorm:"uniqueIndex:ssn_content_to_num;not null"`
+	Name         string     `json:"name"`
+	Overview     string     `json:"overview"`
+	PosterPath   string     `json:"posterPath"`
+	AirDate      *time.Time `json:"airDate,omitempty"`
+	EpisodeCount int        `json:"episodeCount"`
+}
+
+// Cached episode details for show content.
+// Like WatchedEpisode, episodes are identified by season and episode number.
+type Episode struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UpdatedAt     time.Time  `json:"-"`
+	ContentID     int        `json:"-" gorm:"uniqueIndex:ep_content_to_ens;not null"`
+	SeasonNumber  int        `json:"seasonNumber" gorm:"uniqueIndex:ep_content_to_ens;not null"`
+	EpisodeNumber int        `json:"episodeNumber" gorm:"uniqueIndex:ep_content_to_ens;not null"`
+	Name          string     `json:"name"`
+	Overview      string     `json:"overview"`
+	StillPath     string     `json:"stillPath"`
+	AirDate       *time.Time `json:"airDate,omitempty" gorm:"index"`
+	Runtime       int        `json:"runtime"`
+}
+
+func parseTMDBDate(d string) *time.Time {
+	if d == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", d)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// Save season details for show content we have cached.
+func cacheSeasonDetails(db *gorm.DB, tmdbId int, sd TMDBSeasonDetails) {
+	contentId := getContentId(db, SHOW, tmdbId)
+	if contentId == 0 {
+		return
+	}
+	season := Season{
+		ContentID:    contentId,
+		SeasonNumber: sd.SeasonNumber,
+		Name:         sd.Name,
+		Overview:     sd.Overview,
+		PosterPath:   sd.PosterPath,
+		AirDate:      parseTMDBDate(sd.AirDate),
+		EpisodeCount: len(sd.Episodes),
+	}
+	episodes := make([]Episode, len(sd.Episodes))
+	for i, e := range sd.Episodes {
+		episodes[i] = Episode{
+			ContentID:     contentId,
+			SeasonNumber:  e.SeasonNumber,
+			EpisodeNumber: e.EpisodeNumber,
+			Name:          e.Name,
+			Overview:      e.Overview,
+			StillPath:     e.StillPath,
+			AirDate:       parseTMDBDate(e.AirDate),
+			Runtime:       e.Runtime,
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "content_id"}, {Name: "season_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "name", "overview", "poster_path", "air_date", "episode_count"}),
+		}).Create(&season).Error; err != nil {
+			return err
+		}
+		// Episodes can be removed from a season on TMDB, so remove any we no longer have.
+		epNums := make([]int, len(episodes))
+		for i, e := range episodes {
+			epNums[i] = e.EpisodeNumber
+		}
+		q := tx.Where("content_id = ? AND season_number = ?", contentId, sd.SeasonNumber)
+		if len(epNums) > 0 {
+			q = q.Where("episode_number NOT IN ?", epNums)
+		}
+		if err := q.Delete(&Episode{}).Error; err != nil {
+			return err
+		}
+		if len(episodes) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "content_id"}, {Name: "season_number"}, {Name: "episode_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "name", "overview", "still_path", "air_date", "runtime"}),
+		}).CreateInBatches(&episodes, 100).Error
+	})
+	if err != nil {
+		slog.Error("cacheSeasonDetails: Failed to save season", "content_id", contentId, "season", sd.SeasonNumber, "error", err)
+	}
+}
+
+// Get season numbers (excluding specials) of a show that we haven't cached yet.
+func getMissingSeasons(db *gorm.DB, c Content) ([]int, error) {
+	cached := []int{}
+	if res := db.Model(&Season{}).Where("content_id = ?", c.ID).Pluck("season_number", &cached); res.Error != nil {
+		slog.Error("getMissingSeasons: Failed to get cached seasons", "content_id", c.ID, "error", res.Error)
+		return []int{}, res.Error
+	}
+	missing := []int{}
+	for s := 1; s <= int(c.NumberOfSeasons); s++ {
+		if !slices.Contains(cached, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing, nil
+}
+
+// Get shows that don't have all of their seasons (excluding specials) cached.
+// Having some episodes cached isn't enough, the next episode to air
+// is cached from show details without its season.
+func getShowsMissingSeasons(db *gorm.DB, contents []Content) ([]Content, error) {
+	ids := make([]int, len(contents))
+	for i, c := range contents {
+		ids[i] = c.ID
+	}
+	counts := []struct {
+		ContentID int
+		Seasons   uint32
+	}{}
+	res := db.Model(&Season{}).
+		Select("seasons.content_id, COUNT(*) AS seasons").
+		Joins("JOIN contents ON contents.id = seasons.content_id").
+		Where("seasons.content_id IN ? AND seasons.season_number BETWEEN 1 AND contents.number_of_seasons", ids).
+		Group("seasons.content_id").
+		Scan(&counts)
+	if res.Error != nil {
+		slog.Error("getShowsMissingSeasons: Failed to count cached seasons", "error", res.Error)
+		return []Content{}, res.Error
+	}
+	cached := map[int]uint32{}
+	for _, c := range counts {
+		cached[c.ContentID] = c.Seasons
+	}
+	missing := []Content{}
+	for _, c := range contents {
+		if c.Type == SHOW && cached[c.ID] < c.NumberOfSeasons {
+			missing = append(missing, c)
+		}
+	}
+	return missing, nil
+}
+
+// Cache seasons for a show we are missing, and always the latest
+// (where new episodes will be added). Specials (season 0) are
+// only fetched the first time, not all shows have them.
+func cacheShowSeasons(db *gorm.DB, c Content) {
+	if c.Type != SHOW || c.NumberOfSeasons == 0 {
+		return
+	}
+	var cached int64
+	if res := db.Model(&Season{}).Where("content_id = ?", c.ID).Count(&cached); res.Error != nil {
+		slog.Error("cacheShowSeasons: Failed to count cached seasons", "content_id", c.ID, "error", res.Error)
+		return
+	}
+	missing, err := getMissingSeasons(db, c)
+	if err != nil {
+		return
+	}
+	seasons := missing
+	if cached == 0 {
+		seasons = append([]int{0}, seasons...)
+	}
+	if latest := int(c.NumberOfSeasons); !slices.Contains(seasons, latest) {
+		seasons = append(seasons, latest)
+	}
+	tmdbId := strconv.Itoa(c.TmdbID)
+	for _, s := range seasons {
+		// fetchSeasonDetails caches the season for us (seasonDetails
+		// wouldn't if the season is in our in memory cache).
+		if _, err := fetchSeasonDetails(db, tmdbId, strconv.Itoa(s), tmdbDefaultLanguage); err != nil {
+			// Not all shows have specials (season 0).
+			if s != 0 {
+				slog.Error("cacheShowSeasons: Failed to get season details", "content_id", c.ID, "season", s, "error", err)
+			}
+		}
+	}
+}
+
+var (
+	// Shows that are having their seasons cached in the background.
+	showSeasonsCaching   = map[int]bool{}
+	showSeasonsCachingMu sync.Mutex
+)
+
+// Cache seasons for shows in the background, one show at a time.
+// Shows already being cached are skipped.
+func queueCacheShowSeasons(db *gorm.DB, contents []Content) {
+	queued := []Content{}
+	showSeasonsCachingMu.Lock()
+	for _, c := range contents {
+		if !showSeasonsCaching[c.ID] {
+			showSeasonsCaching[c.ID] = true
+			queued = append(queued, c)
+		}
+	}
+	showSeasonsCachingMu.Unlock()
+	if len(queued) == 0 {
+		return
+	}
+	slog.Debug("queueCacheShowSeasons: Caching seasons for shows", "count", len(queued))
+	go func() {
+		for _, c := range queued {
+			cacheShowSeasons(db, c)
+			showSeasonsCachingMu.Lock()
+			delete(showSeasonsCaching, c.ID)
+			showSeasonsCachingMu.Unlock()
+		}
+	}()
+}
+
+// Get episodes that aired in a date range ([from, to)), for shows we have cached.
+func getAiredEpisodes(db *gorm.DB, from time.Time, to time.Time) ([]Episode, error) {
+	episodes := []Episode{}
+	res := db.Where("air_date >= ? AND air_date < ?", from, to).Order("air_date").Find(&episodes)
+	if res.Error != nil {
+		slog.Error("getAiredEpisodes: Failed to get episodes", "error", res.Error)
+		return []Episode{}, res.Error
+	}
+	return episodes, nil
+}
+
+// Get total runtime (minutes) of aired episodes (excluding specials) for shows.
+// Shows without all of their seasons cached won't be included,
+// since a partial sum would be far less than their real runtime.
+func getShowsEpisodeRuntime(db *gorm.DB, shows []Content) map[int]uint32 {
+	runtimes := map[int]uint32{}
+	if len(shows) == 0 {
+		return runtimes
+	}
+	missing, err := getShowsMissingSeasons(db, shows)
+	if err != nil {
+		return runtimes
+	}
+	isMissing := map[int]bool{}
+	for _, c := range missing {
+		isMissing[c.ID] = true
+	}
+	contentIds := []int{}
+	for _, c := range shows {
+		if !isMissing[c.ID] {
+			contentIds = append(contentIds, c.ID)
+		}
+	}
+	if len(contentIds) == 0 {
+		return runtimes
+	}
+	rows := []struct {
+		ContentID int
+		Runtime   uint32
+	}{}
+	res := db.Model(&Episode{}).
+		Select("content_id, SUM(runtime) AS runtime").
+		Where("content_id IN ? AND season_number > 0 AND air_date <= ?", contentIds, time.Now()).
+		Group("content_id").
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("getShowsEpisodeRuntime: Failed to get runtimes", "error", res.Error)
+		return runtimes
+	}
+	for _, r := range rows {
+		if r.Runtime > 0 {
+			runtimes[r.ContentID] = r.Runtime
+		}
+	}
+	return runtimes
+}
+
+type episodeKey struct {
+	ContentID     int
+	SeasonNumber  int
+	EpisodeNumber int
+}
+
+// Get cached episode names for content, keyed by content, season and episode number.
+func getEpisodeNames(db *gorm.DB, contentIds []int) map[episodeKey]string {
+	names := map[episodeKey]string{}
+	if len(contentIds) == 0 {
+		return names
+	}
+	episodes := []Episode{}
+	res := db.Select("content_id", "season_number", "episode_number", "name").Where("content_id IN ?", contentIds).Find(&episodes)
+	if res.Error != nil {
+		slog.Error("getEpisodeNames: Failed to get episodes", "error", res.Error)
+		return names
+	}
+	for _, e := range episodes {
+		names[episodeKey{e.ContentID, e.SeasonNumber, e.EpisodeNumber}] = e.Name
+	}
+	return names
+}
+
+// Fill in names of watched episodes from our cache.
+func addWatchedEpisodeNames(db *gorm.DB, watched []Watched) {
+	contentIds := []int{}
+	for _, w := range watched {
+		if w.Content != nil && w.Content.Type == SHOW && len(w.WatchedEpisodes) > 0 {
+			contentIds = append(contentIds, w.Content.ID)
+		}
+	}
+	names := getEpisodeNames(db, contentIds)
+	if len(names) == 0 {
+		return
+	}
+	for _, w := range watched {
+		if w.Content == nil {
+			continue
+		}
+		for i, we := range w.WatchedEpisodes {
+			w.WatchedEpisodes[i].Name = names[episodeKey{w.Content.ID, we.SeasonNumber, we.EpisodeNumber}]
+		}
+	}
+}
+
+// Fill in episode names for episode activity (for one watched item) from our cache.
+func addActivityEpisodeNames(db *gorm.DB, contentId int, activity []Activity) {
+	names := getEpisodeNames(db, []int{contentId})
+	if len(names) == 0 {
+		return
+	}
+	for i, a := range activity {
+		if a.Data == "" {
+			continue
+		}
+		switch a.Type {
+		case EPISODE_ADDED, EPISODE_ADDED_JF, EPISODE_ADDED_PLEX, EPISODE_REMOVED, EPISODE_RATING_CHANGED, EPISODE_STATUS_CHANGED:
+			var d struct {
+				Season  int `json:"season"`
+				Episode int `json:"episode"`
+			}
+			if err := json.Unmarshal([]byte(a.Data), &d); err != nil {
+				continue
+			}
+			activity[i].EpisodeName = names[episodeKey{contentId, d.Season, d.Episode}]
+		}
+	}
+}
+
+// Save a shows next episode (from its details) to our cache, so
+// upcoming episodes are known before its season is cached.
+func cacheNextEpisode(db *gorm.DB, tmdbId int, e TMDBEpisodeToAir) {
+	contentId := getContentId(db, SHOW, tmdbId)
+	if contentId == 0 || e.EpisodeNumber == 0 {
+		return
+	}
+	ep := Episode{
+		ContentID:     contentId,
+		SeasonNumber:  e.SeasonNumber,
+		EpisodeNumber: e.EpisodeNumber,
+		Name:          e.Name,
+		Overview:      e.Overview,
+		StillPath:     e.StillPath,
+		AirDate:       parseTMDBDate(e.AirDate),
+		Runtime:       e.Runtime,
+	}
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "content_id"}, {Name: "season_number"}, {Name: "episode_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "name", "overview", "still_path", "air_date", "runtime"}),
+	}).Create(&ep)
+	if res.Error != nil {
+		slog.Error("cacheNextEpisode: Failed to save episode", "content_id", contentId, "error", res.Error)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Genre from TMDB, ID is the TMDB genre id.
+type Genre struct {
+	ID   int    `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Name string `json:"name"`
+}
+
+// Keyword from TMDB, ID is the TMDB keyword id.
+type Keyword struct {
+	ID   int    `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Name string `json:"name"`
+}
+
+// Person from TMDB, ID is the TMDB person id.
+type Person struct {
+	ID                 int    `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Name               string `json:"name"`
+	ProfilePath        string `json:"profilePath"`
+	KnownForDepartment string `json:"knownForDepartment"`
+}
+
+type ContentGenre struct {
+	ContentID int    `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	GenreID   int    `json:"-" gorm:"primaryKey;autoIncrement:false;index"`
+	Genre     *Genre `json:"genre,omitempty"`
+}
+
+type ContentKeyword struct {
+	ContentID int      `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	KeywordID int      `json:"-" gorm:"primaryKey;autoIncrement:false;index"`
+	Keyword   *Keyword `json:"keyword,omitempty"`
+}
+
+type ContentCreditType string
+
+const (
+	CREDIT_CAST ContentCreditType = "CAST"
+	CREDIT_CREW ContentCreditType = "CREW"
+)
+
+// A person's credit on cached content.
+type ContentCredit struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	ContentID int               `json:"-" gorm:"index;not null"`
+	PersonID  int               `json:"-" gorm:"index;not null"`
+	Person    *Person           `json:"person,omitempty"`
+	Type      ContentCreditType `json:"type"`
+	// For cast.
+	Character string `json:"character,omitempty"`
+	Order     int    `json:"order"`
+	// For crew.
+	Department string `json:"department,omitempty"`
+	Job        string `json:"job,omitempty"`
+}
+
+// A movie's release date in a country, from TMDB.
+type ContentReleaseDate struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ContentID   int       `json:"-" gorm:"index;not null"`
+	Country     string    `json:"country"`
+	Type        int       `json:"type"`
+	ReleaseDate time.Time `json:"releaseDate"`
+}
+
+// How many watched items (finished) a user has for a genre.
+type WatchedGenreStat struct {
+	GenreID       int     `json:"genreId"`
+	Name          string  `json:"name"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// How many watched items (finished) a user has with a person.
+type WatchedPersonStat struct {
+	PersonID      int     `json:"personId"`
+	Name          string  `json:"name"`
+	ProfilePath   string  `json:"profilePath"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// Get id of our cached content, 0 if not cached.
+func getContentId(db *gorm.DB, contentType ContentType, tmdbId int) int {
+	var id int
+	res := db.Model(&Content{}).Select("id").Where("type = ? AND tmdb_id = ?", contentType, tmdbId).Limit(1).Scan(&id)
+	if res.Error != nil {
+		slog.Error("getContentId: Failed to get content id", "type", contentType, "tmdb_id", tmdbId, "error", res.Error)
+		return 0
+	}
+	return id
+}
+
+// Replace the genres linked to our cached content.
+func saveContentGenres(db *gorm.DB, contentId int, genres []TMDBNamedItem) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentId).Delete(&ContentGenre{}).Error; err != nil {
+			return err
+		}
+		if len(genres) == 0 {
+			return nil
+		}
+		g := make([]Genre, len(genres))
+		cg := make([]ContentGenre, len(genres))
+		for i, v := range genres {
+			g[i] = Genre{ID: v.ID, Name: v.Name}
+			cg[i] = ContentGenre{ContentID: contentId, GenreID: v.ID}
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}).Create(&g).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&cg).Error
+	})
+}
+
+// Replace the keywords linked to our cached content.
+func saveContentKeywords(db *gorm.DB, contentId int, keywords []TMDBNamedItem) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentId).Delete(&ContentKeyword{}).Error; err != nil {
+			return err
+		}
+		if len(keywords) == 0 {
+			return nil
+		}
+		k := make([]Keyword, len(keywords))
+		ck := make([]ContentKeyword, len(keywords))
+		for i, v := range keywords {
+			k[i] = Keyword{ID: v.ID, Name: v.Name}
+			ck[i] = ContentKeyword{ContentID: contentId, KeywordID: v.ID}
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}).Create(&k).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ck).Error
+	})
+}
+
+// Replace the cast and crew linked to our cached content.
+func saveContentCredits(db *gorm.DB, contentId int, credits TMDBContentCredits) error {
+	people := map[int]Person{}
+	cc := []ContentCredit{}
+	for _, v := range credits.Cast {
+		people[v.ID] = Person{ID: v.ID, Name: v.Name, ProfilePath: v.ProfilePath, KnownForDepartment: v.KnownForDepartment}
+		cc = append(cc, ContentCredit{ContentID: contentId, PersonID: v.ID, Type: CREDIT_CAST, Character: v.Character, Order: v.Order})
+	}
+	for _, v := range credits.Crew {
+		people[v.ID] = Person{ID: v.ID, Name: v.Name, ProfilePath: v.ProfilePath, KnownForDepartment: v.KnownForDepartment}
+		cc = append(cc, ContentCredit{ContentID: contentId, PersonID: v.ID, Type: CREDIT_CREW, Department: v.Department, Job: v.Job})
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentId).Delete(&ContentCredit{}).Error; err != nil {
+			return err
+		}
+		if len(cc) == 0 {
+			return nil
+		}
+		p := make([]Person, 0, len(people))
+		for _, v := range people {
+			p = append(p, v)
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "profile_path", "known_for_department"}),
+		}).CreateInBatches(&p, 100).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&cc, 100).Error
+	})
+}
+
+// Save genres, keywords and credits for our cached content.
+// keywords and credits are only replaced when included (not nil),
+// since they are only sometimes appended to detail responses.
+func saveContentMeta(db *gorm.DB, contentType ContentType, tmdbId int, genres []TMDBNamedItem, keywords []TMDBNamedItem, credits *TMDBContentCredits) {
+	contentId := getContentId(db, contentType, tmdbId)
+	if contentId == 0 {
+		// Content isn't cached, nothing to link to.
+		return
+	}
+	if err := saveContentGenres(db, contentId, genres); err != nil {
+		slog.Error("saveContentMeta: Failed to save genres", "content_id", contentId, "error", err)
+	}
+	if keywords != nil {
+		if err := saveContentKeywords(db, contentId, keywords); err != nil {
+			slog.Error("saveContentMeta: Failed to save keywords", "content_id", contentId, "error", err)
+		}
+	}
+	if credits != nil {
+		if err := saveContentCredits(db, contentId, *credits); err != nil {
+			slog.Error("saveContentMeta: Failed to save credits", "content_id", contentId, "error", err)
+		}
+	}
+}
+
+// Save credits for content from a credits request, if the content is cached by us.
+func cacheContentCredits(db *gorm.DB, contentType ContentType, credits TMDBContentCredits) {
+	contentId := getContentId(db, contentType, credits.ID)
+	if contentId == 0 {
+		return
+	}
+	if err := saveContentCredits(db, contentId, credits); err != nil {
+		slog.Error("cacheContentCredits: Failed to save credits", "content_id", contentId, "error", err)
+	}
+}
+
+// Replace the release dates linked to our cached movie.
+func saveContentReleaseDates(db *gorm.DB, contentId int, rd TMDBMovieReleaseDates) error {
+	dates := []ContentReleaseDate{}
+	for _, r := range rd.Results {
+		for _, d := range r.ReleaseDates {
+			dates = append(dates, ContentReleaseDate{ContentID: contentId, Country: strings.ToUpper(r.Iso31661), Type: d.Type, ReleaseDate: d.ReleaseDate})
+		}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentId).Delete(&ContentReleaseDate{}).Error; err != nil {
+			return err
+		}
+		if len(dates) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&dates, 100).Error
+	})
+}
+
+// Save release dates for a movie, if it is cached by us.
+func cacheContentReleaseDates(db *gorm.DB, tmdbId int, rd TMDBMovieReleaseDates) {
+	contentId := getContentId(db, MOVIE, tmdbId)
+	if contentId == 0 {
+		return
+	}
+	if err := saveContentReleaseDates(db, contentId, rd); err != nil {
+		slog.Error("cacheContentReleaseDates: Failed to save release dates", "content_id", contentId, "error", err)
+	}
+}
+
+// Users finished watched items, used as base for stats queries.
+func finishedWatchedQuery(db *gorm.DB, userId uint) *gorm.DB {
+	return db.Model(&Watched{}).Where("watcheds.user_id = ? AND watcheds.status = ?", userId, FINISHED)
+}
+
+// Get count of finished content per genre for a user.
+func getWatchedGenreStats(db *gorm.DB, userId uint, contentType ContentType) ([]WatchedGenreStat, error) {
+	q := finishedWatchedQuery(db, userId)
+	if contentType != "" {
+		q = q.Joins("JOIN contents ON contents.id = watcheds.content_id").Where("contents.type = ?", contentType)
+	}
+	stats := []WatchedGenreStat{}
+	if res := genreStatsQuery(q).Scan(&stats); res.Error != nil {
+		slog.Error("getWatchedGenreStats: Failed to get stats", "user_id", userId, "error", res.Error)
+		return []WatchedGenreStat{}, errors.New("failed to get genre stats")
+	}
+	return stats, nil
+}
+
+// Count genres of watched items in `q`.
+func genreStatsQuery(q *gorm.DB) *gorm.DB {
+	return q.
+		Select("genres.id AS genre_id, genres.name, COUNT(*) AS count, COALESCE(AVG(NULLIF(watcheds.rating, 0)), 0) AS average_rating").
+		Joins("JOIN content_genres ON content_genres.content_id = watcheds.content_id").
+		Joins("JOIN genres ON genres.id = content_genres.genre_id").
+		Group("genres.id").
+		Order("count DESC")
+}
+
+// Get people that appear most in a users finished content.
+// creditType - cast or crew, job can optionally filter crew (eg: Director).
+func getWatchedPersonStats(db *gorm.DB, userId uint, creditType ContentCreditType, job string, limit int) ([]WatchedPersonStat, error) {
+	stats := []WatchedPersonStat{}
+	if res := personStatsQuery(finishedWatchedQuery(db, userId), creditType, job).Limit(limit).Scan(&stats); res.Error != nil {
+		slog.Error("getWatchedPersonStats: Failed to get stats", "user_id", userId, "error", res.Error)
+		return []WatchedPersonStat{}, errors.New("failed to get person stats")
+	}
+	return stats, nil
+}
+
+// Count people credited in watched items in `q`.
+func personStatsQuery(q *gorm.DB, creditType ContentCreditType, job string) *gorm.DB {
+	// People can have multiple credits (characters or jobs) on the same content,
+	// only count each once so they aren't weighted more in the count and average.
+	credits := "SELECT DISTINCT content_id, person_id FROM content_credits WHERE type = ?"
+	vars := []interface{}{creditType}
+	if job != "" {
+		credits += " AND job = ?"
+		vars = append(vars, job)
+	}
+	return q.
+		Select("people.id AS person_id, people.name, people.profile_path, COUNT(watcheds.id) AS count, COALESCE(AVG(NULLIF(watcheds.rating, 0)), 0) AS average_rating").
+		Joins("JOIN ("+credits+") AS credits ON credits.content_id = watcheds.content_id", vars...).
+		Joins("JOIN people ON people.id = credits.person_id").
+		Group("people.id").
+		Order("count DESC")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Max content refreshed each time the task runs.
+	contentRefreshBatchSize = 50
+	// Delay between each refresh, so we don't hog the TMDB rate limit.
+	contentRefreshDelay = 500 * time.Millisecond
+)
+
+// Get content that is due a refresh, most important first:
+//  1. Content that has never been refreshed (cached before we tracked updates).
+//  2. Airing shows and content releasing soon (or recently), refreshed daily.
+//  3. Content recently watched by anyone, refreshed every 3 days.
+//  4. Everything else, refreshed monthly.
+func getStaleContent(db *gorm.DB, limit int) ([]Content, error) {
+	now := time.Now()
+	day := now.AddDate(0, 0, -1)
+	threeDays := now.AddDate(0, 0, -3)
+	month := now.AddDate(0, -1, 0)
+	activeStatuses := []string{"Returning Series", "In Production", "Planned", "Post Production", "Rumored"}
+	recentlyWatched := db.Model(&Watched{}).Select("content_id").Where("content_id IS NOT NULL AND updated_at > ?", month)
+
+	var content []Content
+	res := db.
+		Where("updated_at IS NULL").
+		Or("(status IN ? OR release_date > ?) AND updated_at < ?", activeStatuses, month, day).
+		Or("id IN (?) AND updated_at < ?", recentlyWatched, threeDays).
+		Or("updated_at < ?", month).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "updated_at IS NOT NULL, CASE WHEN status IN (?) OR release_date > ? THEN 0 WHEN id IN (?) THEN 1 ELSE 2 END, updated_at ASC",
+			Vars:               []interface{}{activeStatuses, month, recentlyWatched},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&content)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return content, nil
+}
+
+// Refresh one content row with the latest details from TMDB.
+func refreshContent(db *gorm.DB, c Content) error {
+	resp, err := tmdbAPIRequestWithCache("/"+string(c.Type)+"/"+strconv.Itoa(c.TmdbID), map[string]string{"append_to_response": getContentMetaAppend(c.Type)}, false)
+	if err != nil {
+		return err
+	}
+	if c.Type == MOVIE {
+		d := new(TMDBMovieDetails)
+		if err := json.Unmarshal(resp, &d); err != nil {
+			return err
+		}
+		_, err = cacheContentMovie(db, *d, true)
+	} else {
+		d := new(TMDBShowDetails)
+		if err := json.Unmarshal(resp, &d); err != nil {
+			return err
+		}
+		_, err = cacheContentTv(db, *d, true)
+		c.NumberOfSeasons = d.NumberOfSeasons
+	}
+	if err != nil {
+		return err
+	}
+	cacheContentTranslations(db, c)
+	cacheShowSeasons(db, c)
+	return nil
+}
+
+// Refresh a batch of our cached content that is out of date,
+// so details shown on watched lists (status, episode counts) stay current.
+func refreshStaleContent(db *gorm.DB) {
+	content, err := getStaleContent(db, contentRefreshBatchSize)
+	if err != nil {
+		slog.Error("refreshStaleContent: Failed to get stale content", "error", err)
+		return
+	}
+	if len(content) == 0 {
+		return
+	}
+	slog.Debug("refreshStaleContent: Refreshing content", "count", len(content))
+	failed := 0
+	for i, c := range content {
+		if i > 0 {
+			time.Sleep(contentRefreshDelay)
+		}
+		if err := refreshContent(db, c); err != nil {
+			slog.Error("refreshStaleContent: Failed to refresh content", "id", c.ID, "tmdb_id", c.TmdbID, "type", c.Type, "error", err)
+			failed++
+			// Push it to the back of the queue, so one broken
+			// item can't stop everything else being refreshed.
+			db.Model(&Content{}).Where("id = ?", c.ID).UpdateColumn("updated_at", time.Now())
+		}
+	}
+	slog.Info("refreshStaleContent: Refreshed content", "refreshed", len(content)-failed, "failed", failed)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Language our cached `Content` is stored in, and the
+// fallback when a translation doesn't exist.
+const tmdbDefaultLanguage = "en-US"
+
+// Localized details for cached content.
+type ContentTranslation struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+	ContentID int       `json:"-" gorm:"uniqueIndex:ctntolangidx;not null"`
+	// Language tag (eg: de-DE).
+	Language string `json:"language" gorm:"uniqueIndex:ctntolangidx;not null"`
+	Title    string `json:"title"`
+	Overview string `json:"overview"`
+}
+
+// From `GET /movie/{id}/translations` or `GET /tv/{id}/translations`.
+type TMDBTranslations struct {
+	ID           int `json:"id"`
+	Translations []struct {
+		Iso31661 string `json:"iso_3166_1"`
+		Iso6391  string `json:"iso_639_1"`
+		Data     struct {
+			// Movies
+			Title string `json:"title"`
+			// Shows
+			Name     string `json:"name"`
+			Overview string `json:"overview"`
+		} `json:"data"`
+	} `json:"translations"`
+}
+
+func isDefaultContentLanguage(lang string) bool {
+	return lang == "" || strings.EqualFold(lang, tmdbDefaultLanguage)
+}
+
+// Get language to use for a user, their own preference,
+// then the servers default, then our default.
+func getUserLanguage(userLang *string) string {
+	if userLang != nil && *userLang != "" {
+		return *userLang
+	}
+	if Config.DEFAULT_LANGUAGE != "" {
+		return Config.DEFAULT_LANGUAGE
+	}
+	return tmdbDefaultLanguage
+}
+
+// Languages (other than our default) that are used by our users.
+func getContentLanguagesInUse(db *gorm.DB) []string {
+	langs := []string{}
+	if res := db.Model(&User{}).Distinct().Where("language IS NOT NULL AND language != ''").Pluck("language", &langs); res.Error != nil {
+		slog.Error("getContentLanguagesInUse: Failed to get languages from db", "error", res.Error)
+	}
+	if Config.DEFAULT_LANGUAGE != "" {
+		langs = append(langs, Config.DEFAULT_LANGUAGE)
+	}
+	inUse := []string{}
+	for _, l := range langs {
+		if !isDefaultContentLanguage(l) && !containsFold(inUse, l) {
+			inUse = append(inUse, l)
+		}
+	}
+	return inUse
+}
+
+func containsFold(s []string, v string) bool {
+	for _, i := range s {
+		if strings.EqualFold(i, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func saveContentTranslations(db *gorm.DB, t []ContentTranslation) {
+	if len(t) == 0 {
+		return
+	}
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "content_id"}, {Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "title", "overview"}),
+	}).Create(&t)
+	if res.Error != nil {
+		slog.Error("saveContentTranslations: Failed to save translations", "error", res.Error)
+	}
+}
+
+// Save localized details (from a details request in `lang`) for content we have cached.
+func saveContentTranslation(db *gorm.DB, contentType ContentType, tmdbId int, lang string, title string, overview string) {
+	var contentId int
+	res := db.Model(&Content{}).Select("id").Where("type = ? AND tmdb_id = ?", contentType, tmdbId).Limit(1).Scan(&contentId)
+	if res.Error != nil || contentId == 0 {
+		// We only store translations for content we have cached.
+		return
+	}
+	saveContentTranslations(db, []ContentTranslation{{ContentID: contentId, Language: lang, Title: title, Overview: overview}})
+}
+
+// Fetch and save translations for content, only for the languages our users use.
+func cacheContentTranslations(db *gorm.DB, c Content) {
+	langs := getContentLanguagesInUse(db)
+	if len(langs) == 0 || c.ID == 0 {
+		return
+	}
+	resp := new(TMDBTranslations)
+	err := tmdbRequest("/"+string(c.Type)+"/"+strconv.Itoa(c.TmdbID)+"/translations", map[string]string{}, &resp)
+	if err != nil {
+		slog.Error("cacheContentTranslations: Failed to get translations", "id", c.ID, "error", err)
+		return
+	}
+	toSave := []ContentTranslation{}
+	for _, l := range langs {
+		lang, region, _ := strings.Cut(l, "-")
+		found := false
+		var ct ContentTranslation
+		for _, t := range resp.Translations {
+			if !strings.EqualFold(t.Iso6391, lang) {
+				continue
+			}
+			// Prefer exact region match, but take any for the language.
+			exact := region != "" && strings.EqualFold(t.Iso31661, region)
+			if found && !exact {
+				continue
+			}
+			title := t.Data.Title
+			if c.Type == SHOW {
+				title = t.Data.Name
+			}
+			ct = ContentTranslation{ContentID: c.ID, Language: l, Title: title, Overview: t.Data.Overview}
+			found = true
+			if exact {
+				break
+			}
+		}
+		if found {
+			toSave = append(toSave, ct)
+		}
+	}
+	saveContentTranslations(db, toSave)
+}
+
+// Replace content titles/overviews in watched list with those
+// in `lang`, keeping the default language where none exist.
+func localizeWatchedContent(db *gorm.DB, watched []Watched, lang string) {
+	if isDefaultContentLanguage(lang) {
+		return
+	}
+	ids := []int{}
+	for _, w := range watched {
+		if w.Content != nil {
+			ids = append(ids, w.Content.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	translations := []ContentTranslation{}
+	if res := db.Where("language = ? AND content_id IN ?", lang, ids).Find(&translations); res.Error != nil {
+		slog.Error("localizeWatchedContent: Failed to get translations", "error", res.Error)
+		return
+	}
+	byContent := map[int]ContentTranslation{}
+	for _, t := range translations {
+		byContent[t.ContentID] = t
+	}
+	for _, w := range watched {
+		if w.Content == nil {
+			continue
+		}
+		if t, ok := byContent[w.Content.ID]; ok {
+			if t.Title != "" {
+				w.Content.Title = t.Title
+			}
+			if t.Overview != "" {
+				w.Content.Overview = t.Overview
+			}
+		}
+	}
+}
+
+// Cache translations in `lang` for all content on a users watched list
+// that doesn't have them yet (ran when a user changes their language).
+func cacheWatchedContentTranslations(db *gorm.DB, userId uint, lang string) {
+	contents := []Content{}
+	res := db.Model(&Content{}).
+		Where("id IN (?)", db.Model(&Watched{}).Select("content_id").Where("user_id = ? AND content_id IS NOT NULL", userId)).
+		Where("id NOT IN (?)", db.Model(&ContentTranslation{}).Select("content_id").Where("language = ?", lang)).
+		Find(&contents)
+	if res.Error != nil {
+		slog.Error("cacheWatchedContentTranslations: Failed to get content missing translations", "user_id", userId, "error", res.Error)
+		return
+	}
+	slog.Info("cacheWatchedContentTranslations: Caching translations", "user_id", userId, "lang", lang, "count", len(contents))
+	for _, c := range contents {
+		cacheContentTranslations(db, c)
+	}
+}
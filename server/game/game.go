@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/sbondCo/Watcharr/httpc"
 )
 
 const (
@@ -19,6 +21,10 @@ const (
 
 var tokenRefreshJobCancel context.CancelFunc
 
+// IGDB queries are POST requests, so safe to retry.
+// IGDB allows 4 requests per second.
+var httpClient = httpc.New("igdb", httpc.Options{Timeout: 15 * time.Second, MaxRetries: 3, RetryNonIdempotent: true, RateLimit: 4})
+
 type IGDB struct {
 	ClientID           *string   `json:"clientId,omitempty"`
 	ClientSecret       *string   `json:"clientSecret,omitempty"`
@@ -63,7 +69,7 @@ func (i *IGDB) req(host string, ep string, p map[string]string, b string, resp i
 		req.Header.Add("Authorization", "Bearer "+i.AccessToken)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -119,7 +125,7 @@ func (i *IGDB) refreshToken(ctx context.Context) {
 			slog.Info("IGDB refreshToken: Token expired (or is near expiry date)")
 			r, err := i.getNewAccessToken()
 			if err != nil {
-				slog.Error("IGDB refreshToken: Error refreshing token (retrying in 60s):", err)
+				slog.Error("IGDB refreshToken: Error refreshing token (retrying in 60s):", "error", err)
 				exp = time.After(60 * time.Second)
 			} else {
 				slog.Info("IGDB refreshToken: Token successfully refreshed")
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GoalType string
+
+var (
+	// Movies watched (rewatches count once).
+	GOAL_MOVIES GoalType = "MOVIES"
+	// Shows finished.
+	GOAL_SHOWS GoalType = "SHOWS"
+	// Episodes watched (rewatches count each time).
+	GOAL_EPISODES GoalType = "EPISODES"
+	// Games finished.
+	GOAL_GAMES GoalType = "GAMES"
+)
+
+// Progress milestones (percent) users are notified at.
+var goalMilestones = []int{25, 50, 75, 100}
+
+// A users goal for a year (eg: watch 100 movies in 2026).
+// Goals without a user are instance wide challenges, set by admins.
+type Goal struct {
+	GormModel
+	UserID *uint    `json:"-" gorm:"index"`
+	Name   string   `json:"name"`
+	Year   int      `json:"year" gorm:"not null;index"`
+	Type   GoalType `json:"type" gorm:"not null"`
+	Target int      `json:"target" gorm:"not null"`
+	// Notify user when milestones are reached (only for user goals).
+	Notify bool `json:"notify" gorm:"default:false;not null"`
+	// Highest milestone the user has been notified of.
+	NotifiedMilestone int `json:"-" gorm:"default:0;not null"`
+}
+
+type GoalRequest struct {
+	Name   string   `json:"name" binding:"max=100"`
+	Year   int      `json:"year" binding:"required,min=1900,max=3000"`
+	Type   GoalType `json:"type" binding:"required,oneof=MOVIES SHOWS EPISODES GAMES"`
+	Target int      `json:"target" binding:"required,min=1,max=100000"`
+	Notify bool     `json:"notify"`
+}
+
+type GoalProgress struct {
+	Goal
+	Challenge bool `json:"challenge"`
+	Progress  int  `json:"progress"`
+	Completed bool `json:"completed"`
+}
+
+type GoalsResponse struct {
+	Goals      []GoalProgress `json:"goals"`
+	Challenges []GoalProgress `json:"challenges"`
+}
+
+type GoalLeaderboardEntry struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	Progress int    `json:"progress"`
+	// If this entry is for the user viewing the leaderboard.
+	Me bool `json:"me"`
+}
+
+// Get how far a user is through a goal.
+func getGoalProgress(db *gorm.DB, userId uint, g Goal) (int, error) {
+	from := time.Date(g.Year, 1, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(1, 0, 0)
+	var (
+		watches []userWatch
+		err     error
+	)
+	switch g.Type {
+	case GOAL_MOVIES:
+		watches, err = getFinishedWatches(db, userId, string(MOVIE), from, to)
+	case GOAL_SHOWS:
+		watches, err = getFinishedWatches(db, userId, string(SHOW), from, to)
+	case GOAL_GAMES:
+		watches, err = getFinishedWatches(db, userId, "game", from, to)
+	case GOAL_EPISODES:
+		watches, err = getEpisodeWatches(db, userId, from, to)
+		return len(watches), err
+	default:
+		return 0, errors.New("unknown goal type")
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Count each item once.
+	items := map[uint]bool{}
+	for _, w := range watches {
+		items[w.WatchedID] = true
+	}
+	return len(items), nil
+}
+
+func newGoalProgress(db *gorm.DB, userId uint, g Goal) (GoalProgress, error) {
+	progress, err := getGoalProgress(db, userId, g)
+	if err != nil {
+		return GoalProgress{}, err
+	}
+	return GoalProgress{Goal: g, Challenge: g.UserID == nil, Progress: progress, Completed: progress >= g.Target}, nil
+}
+
+// Get users goals and instance challenges, with their progress.
+// Only for `year` if it's not zero.
+func getGoals(db *gorm.DB, userId uint, year int) (GoalsResponse, error) {
+	q := db.Where("user_id = ? OR user_id IS NULL", userId)
+	if year != 0 {
+		q = q.Where("year = ?", year)
+	}
+	goals := []Goal{}
+	if res := q.Order("year DESC").Order("id").Find(&goals); res.Error != nil {
+		slog.Error("getGoals: Failed to get goals", "user_id", userId, "error", res.Error)
+		return GoalsResponse{}, errors.New("failed to get goals")
+	}
+	resp := GoalsResponse{Goals: []GoalProgress{}, Challenges: []GoalProgress{}}
+	for _, g := range goals {
+		gp, err := newGoalProgress(db, userId, g)
+		if err != nil {
+			return GoalsResponse{}, errors.New("failed to get goal progress")
+		}
+		if gp.Challenge {
+			resp.Challenges = append(resp.Challenges, gp)
+		} else {
+			resp.Goals = append(resp.Goals, gp)
+		}
+	}
+	return resp, nil
+}
+
+// Add a goal, for a user or as an instance challenge when `userId` is nil.
+func addGoal(db *gorm.DB, userId *uint, gr GoalRequest) (Goal, error) {
+	g := Goal{UserID: userId, Name: gr.Name, Year: gr.Year, Type: gr.Type, Target: gr.Target, Notify: gr.Notify && userId != nil}
+	// Don't notify of milestones already reached.
+	if g.Notify {
+		if progress, err := getGoalProgress(db, *userId, g); err == nil {
+			g.NotifiedMilestone = goalMilestone(progress, g.Target)
+		}
+	}
+	if res := db.Create(&g); res.Error != nil {
+		slog.Error("addGoal: Failed to add goal", "error", res.Error)
+		return Goal{}, errors.New("failed to add goal")
+	}
+	return g, nil
+}
+
+// Update a goal, for a user or an instance challenge when `userId` is nil.
+func updateGoal(db *gorm.DB, userId *uint, id uint, gr GoalRequest) (Goal, error) {
+	g := new(Goal)
+	if res := goalOwnerQuery(db, userId).Where("id = ?", id).Take(&g); res.Error != nil {
+		return Goal{}, errors.New("goal does not exist")
+	}
+	targetChanged := g.Target != gr.Target || g.Type != gr.Type || g.Year != gr.Year
+	g.Name = gr.Name
+	g.Year = gr.Year
+	g.Type = gr.Type
+	g.Target = gr.Target
+	g.Notify = gr.Notify && userId != nil
+	if targetChanged && g.Notify {
+		if progress, err := getGoalProgress(db, *userId, *g); err == nil {
+			g.NotifiedMilestone = goalMilestone(progress, g.Target)
+		}
+	}
+	if res := db.Save(&g); res.Error != nil {
+		slog.Error("updateGoal: Failed to update goal", "id", id, "error", res.Error)
+		return Goal{}, errors.New("failed to update goal")
+	}
+	return *g, nil
+}
+
+// Delete a goal, for a user or an instance challenge when `userId` is nil.
+func deleteGoal(db *gorm.DB, userId *uint, id uint) error {
+	res := goalOwnerQuery(db, userId).Where("id = ?", id).Delete(&Goal{})
+	if res.Error != nil {
+		slog.Error("deleteGoal: Failed to delete goal", "id", id, "error", res.Error)
+		return errors.New("failed to delete goal")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("goal does not exist")
+	}
+	return nil
+}
+
+func goalOwnerQuery(db *gorm.DB, userId *uint) *gorm.DB {
+	if userId == nil {
+		return db.Where("user_id IS NULL")
+	}
+	return db.Where("user_id = ?", *userId)
+}
+
+// Get progress of a challenge for the user and users they follow (with public profiles).
+func getChallengeLeaderboard(db *gorm.DB, userId uint, id uint) ([]GoalLeaderboardEntry, error) {
+	g := new(Goal)
+	if res := db.Where("id = ? AND user_id IS NULL", id).Take(&g); res.Error != nil {
+		return []GoalLeaderboardEntry{}, errors.New("challenge does not exist")
+	}
+	users := []User{}
+	res := db.Model(&User{}).
+		Select("id", "username").
+		Where("id = ? OR (id IN (SELECT followed_user_id FROM follows WHERE user_id = ?) AND (private IS NULL OR private = ?))", userId, userId, false).
+		Find(&users)
+	if res.Error != nil {
+		slog.Error("getChallengeLeaderboard: Failed to get users", "user_id", userId, "error", res.Error)
+		return []GoalLeaderboardEntry{}, errors.New("failed to get leaderboard")
+	}
+	entries := []GoalLeaderboardEntry{}
+	for _, u := range users {
+		progress, err := getGoalProgress(db, u.ID, *g)
+		if err != nil {
+			return []GoalLeaderboardEntry{}, errors.New("failed to get leaderboard")
+		}
+		entries = append(entries, GoalLeaderboardEntry{UserID: u.ID, Username: u.Username, Progress: progress, Me: u.ID == userId})
+	}
+	slices.SortStableFunc(entries, func(a, b GoalLeaderboardEntry) int {
+		return b.Progress - a.Progress
+	})
+	return entries, nil
+}
+
+// Highest milestone reached for progress.
+func goalMilestone(progress int, target int) int {
+	reached := 0
+	for _, m := range goalMilestones {
+		if progress*100 >= target*m {
+			reached = m
+		}
+	}
+	return reached
+}
+
+// Notify users of milestones reached on this years goals.
+func checkGoalMilestones(db *gorm.DB) {
+	goals := []Goal{}
+	if res := db.Where("user_id IS NOT NULL AND notify = ? AND year = ? AND notified_milestone < ?", true, time.Now().Year(), 100).Find(&goals); res.Error != nil {
+		slog.Error("checkGoalMilestones: Failed to get goals", "error", res.Error)
+		return
+	}
+	for _, g := range goals {
+		progress, err := getGoalProgress(db, *g.UserID, g)
+		if err != nil {
+			continue
+		}
+		m := goalMilestone(progress, g.Target)
+		if m <= g.NotifiedMilestone {
+			continue
+		}
+		if res := db.Model(&g).Update("notified_milestone", m); res.Error != nil {
+			slog.Error("checkGoalMilestones: Failed to update goal", "id", g.ID, "error", res.Error)
+			continue
+		}
+		name := g.Name
+		if name == "" {
+			name = fmt.Sprintf("%d %s in %d", g.Target, goalTypeName(g.Type), g.Year)
+		}
+		msg := fmt.Sprintf("You're %d%% of the way to your goal: %s.", m, name)
+		if m == 100 {
+			msg = "You completed your goal: " + name + "!"
+		}
+		data, _ := json.Marshal(map[string]interface{}{"goalId": g.ID, "milestone": m, "progress": progress})
+		addNotification(db, *g.UserID, NotificationAddRequest{Type: NOTIFICATION_GOAL_MILESTONE, Message: msg, Data: string(data)})
+	}
+}
+
+func goalTypeName(t GoalType) string {
+	switch t {
+	case GOAL_MOVIES:
+		return "movies"
+	case GOAL_SHOWS:
+		return "shows"
+	case GOAL_EPISODES:
+		return "episodes"
+	case GOAL_GAMES:
+		return "games"
+	}
+	return string(t)
+}
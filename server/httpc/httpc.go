@@ -0,0 +1,295 @@
+// Package httpc is the shared client for all outbound http requests (tmdb, igdb, arr, jellyfin, plex...).
+//
+// Each service gets its own timeout, retries (with jitter) on
+// 429/5xx responses, an optional rate limit and a circuit breaker
+// per host, so one slow or down service can't hang our handlers.
+package httpc
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("service unavailable, too many recent failures")
+
+type Options struct {
+	// Timeout for each attempt (including reading the body).
+	Timeout time.Duration
+	// Max number of retries after the first attempt fails.
+	MaxRetries int
+	// Base delay before retrying, doubled every retry (with jitter).
+	RetryDelay time.Duration
+	// Retry requests with non idempotent methods (ex, POST) on 5xx too.
+	// Otherwise they are only retried on 429, when we know the
+	// request wasn't processed. Useful for APIs that query using POST.
+	RetryNonIdempotent bool
+	// Max requests per second, zero for unlimited.
+	RateLimit int
+	// Consecutive failures before the circuit opens for a host.
+	BreakerThreshold int
+	// How long a hosts circuit stays open before letting a request through.
+	BreakerCooldown time.Duration
+	// Transport to make requests with, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// Requests made by a service and how they went.
+type Metrics struct {
+	Service string `json:"service"`
+	// Requests made through this service (not including retries).
+	Requests int64 `json:"requests"`
+	// Requests that errored or returned a 5xx/429 after all retries.
+	Failures int64 `json:"failures"`
+	Retries  int64 `json:"retries"`
+	// Requests not made because the circuit was open.
+	Rejected int64 `json:"rejected"`
+	// Average time requests took (including retries), in milliseconds.
+	AvgLatencyMs int64 `json:"avgLatencyMs"`
+	// Hosts that currently have an open circuit.
+	OpenCircuits []string `json:"openCircuits"`
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+type Service struct {
+	name   string
+	opts   Options
+	client *http.Client
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	nextSlot time.Time
+	metrics  Metrics
+	latency  time.Duration
+}
+
+var (
+	services   []*Service
+	servicesMu sync.Mutex
+)
+
+// Create a new service, defaults are used for unset options.
+func New(name string, opts Options) *Service {
+	if opts.Timeout == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = 500 * time.Millisecond
+	}
+	if opts.BreakerThreshold == 0 {
+		opts.BreakerThreshold = 5
+	}
+	if opts.BreakerCooldown == 0 {
+		opts.BreakerCooldown = 30 * time.Second
+	}
+	s := &Service{
+		name:     name,
+		opts:     opts,
+		client:   &http.Client{Timeout: opts.Timeout, Transport: opts.Transport},
+		breakers: map[string]*breaker{},
+		metrics:  Metrics{Service: name},
+	}
+	servicesMu.Lock()
+	services = append(services, s)
+	servicesMu.Unlock()
+	return s
+}
+
+// Get metrics for all services.
+func AllMetrics() []Metrics {
+	servicesMu.Lock()
+	defer servicesMu.Unlock()
+	m := make([]Metrics, len(services))
+	for i, s := range services {
+		m[i] = s.Metrics()
+	}
+	return m
+}
+
+func (s *Service) Metrics() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.metrics
+	if m.Requests > 0 {
+		m.AvgLatencyMs = s.latency.Milliseconds() / m.Requests
+	}
+	m.OpenCircuits = []string{}
+	for h, b := range s.breakers {
+		if time.Now().Before(b.openUntil) {
+			m.OpenCircuits = append(m.OpenCircuits, h)
+		}
+	}
+	slices.Sort(m.OpenCircuits)
+	return m
+}
+
+func (s *Service) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.Do(req)
+}
+
+func (s *Service) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return s.Do(req)
+}
+
+// Send request, retrying when it fails and is safe to do so.
+// Requests with a body must be created with http.NewRequest (so GetBody is set)
+// for them to be retried.
+func (s *Service) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	start := time.Now()
+	s.mu.Lock()
+	s.metrics.Requests++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.latency += time.Since(start)
+		s.mu.Unlock()
+	}()
+
+	if !s.allow(host) {
+		s.mu.Lock()
+		s.metrics.Rejected++
+		s.mu.Unlock()
+		slog.Warn("httpc: Circuit open, not sending request", "service", s.name, "host", host)
+		return nil, ErrCircuitOpen
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+	for attempt := 0; ; attempt++ {
+		s.waitForSlot()
+		if attempt > 0 && req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, berr
+			}
+			req.Body = body
+		}
+		res, err = s.client.Do(req)
+		// Bodies we can't resend are never retried.
+		if !s.shouldRetry(req, res, err) || attempt >= s.opts.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		delay := s.retryDelay(attempt, res)
+		slog.Debug("httpc: Retrying request", "service", s.name, "host", host, "attempt", attempt+1, "delay", delay, "error", err)
+		if res != nil {
+			// Drain so the connection can be reused.
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		s.mu.Lock()
+		s.metrics.Retries++
+		s.mu.Unlock()
+		time.Sleep(delay)
+	}
+	failed := err != nil || res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+	s.record(host, failed)
+	return res, err
+}
+
+func (s *Service) shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		return s.canRetryMethod(req.Method)
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if res.StatusCode >= 500 {
+		return s.canRetryMethod(req.Method)
+	}
+	return false
+}
+
+func (s *Service) canRetryMethod(method string) bool {
+	if s.opts.RetryNonIdempotent {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPut || method == http.MethodDelete
+}
+
+// Delay before next attempt, honouring the Retry-After header if
+// the server sent one, otherwise exponential backoff with full jitter.
+func (s *Service) retryDelay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if ra, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && ra >= 0 {
+			return min(time.Duration(ra)*time.Second, 30*time.Second)
+		}
+	}
+	backoff := s.opts.RetryDelay * (1 << attempt)
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// Block until we can send another request without going over our rate limit.
+func (s *Service) waitForSlot() {
+	if s.opts.RateLimit <= 0 {
+		return
+	}
+	interval := time.Second / time.Duration(s.opts.RateLimit)
+	s.mu.Lock()
+	now := time.Now()
+	slot := s.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	s.nextSlot = slot.Add(interval)
+	s.mu.Unlock()
+	time.Sleep(time.Until(slot))
+}
+
+// If the circuit for this host is closed (or half open and ready to try again).
+func (s *Service) allow(host string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[host]
+	if !ok || b.failures < s.opts.BreakerThreshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) {
+		return false
+	}
+	// Half open, let this request through. If it fails the circuit opens again.
+	b.openUntil = time.Now().Add(s.opts.BreakerCooldown)
+	return true
+}
+
+func (s *Service) record(host string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[host]
+	if !ok {
+		b = &breaker{}
+		s.breakers[host] = b
+	}
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	s.metrics.Failures++
+	b.failures++
+	if b.failures == s.opts.BreakerThreshold {
+		slog.Warn("httpc: Too many failures, opening circuit", "service", s.name, "host", host, "cooldown", s.opts.BreakerCooldown)
+		b.openUntil = time.Now().Add(s.opts.BreakerCooldown)
+	}
+}
@@ -0,0 +1,231 @@
+package httpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Server that responds with `statuses` in order, repeating the last one.
+func newStatusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	hits := new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1)) - 1
+		w.WriteHeader(statuses[min(n, len(statuses)-1)])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestDoRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		opts           Options
+		statuses       []int
+		wantStatus     int
+		wantAttempts   int32
+		wantRetries    int64
+		wantFailures   int64
+		withSingleBody bool
+	}{
+		{
+			name:         "success is not retried",
+			method:       http.MethodGet,
+			opts:         Options{MaxRetries: 3},
+			statuses:     []int{200},
+			wantStatus:   200,
+			wantAttempts: 1,
+		},
+		{
+			name:         "client error is not retried",
+			method:       http.MethodGet,
+			opts:         Options{MaxRetries: 3},
+			statuses:     []int{404},
+			wantStatus:   404,
+			wantAttempts: 1,
+		},
+		{
+			name:         "get is retried on 5xx until it succeeds",
+			method:       http.MethodGet,
+			opts:         Options{MaxRetries: 3},
+			statuses:     []int{503, 502, 200},
+			wantStatus:   200,
+			wantAttempts: 3,
+			wantRetries:  2,
+		},
+		{
+			name:         "get stops retrying after max retries",
+			method:       http.MethodGet,
+			opts:         Options{MaxRetries: 2},
+			statuses:     []int{500},
+			wantStatus:   500,
+			wantAttempts: 3,
+			wantRetries:  2,
+			wantFailures: 1,
+		},
+		{
+			name:         "post is not retried on 5xx",
+			method:       http.MethodPost,
+			opts:         Options{MaxRetries: 3},
+			statuses:     []int{500, 200},
+			wantStatus:   500,
+			wantAttempts: 1,
+			wantFailures: 1,
+		},
+		{
+			name:         "post is retried on 429",
+			method:       http.MethodPost,
+			opts:         Options{MaxRetries: 3},
+			statuses:     []int{429, 200},
+			wantStatus:   200,
+			wantAttempts: 2,
+			wantRetries:  1,
+		},
+		{
+			name:         "post is retried on 5xx when non idempotent retries are allowed",
+			method:       http.MethodPost,
+			opts:         Options{MaxRetries: 3, RetryNonIdempotent: true},
+			statuses:     []int{500, 200},
+			wantStatus:   200,
+			wantAttempts: 2,
+			wantRetries:  1,
+		},
+		{
+			name:           "body that can't be resent is not retried",
+			method:         http.MethodPut,
+			opts:           Options{MaxRetries: 3},
+			statuses:       []int{500, 200},
+			wantStatus:     500,
+			wantAttempts:   1,
+			wantFailures:   1,
+			withSingleBody: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := newStatusServer(t, tt.statuses...)
+			tt.opts.RetryDelay = time.Millisecond
+			s := New("test", tt.opts)
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.withSingleBody {
+				req.GetBody = nil
+			}
+			res, err := s.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if got := hits.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			m := s.Metrics()
+			if m.Retries != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", m.Retries, tt.wantRetries)
+			}
+			if m.Failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", m.Failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestDoCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	// Each step sends one request after optionally waiting.
+	type step struct {
+		wait       time.Duration
+		wantOpen   bool
+		wantStatus int
+	}
+	tests := []struct {
+		name     string
+		statuses []int
+		steps    []step
+		// Requests that should have reached the server.
+		wantHits int32
+	}{
+		{
+			name:     "opens after threshold failures",
+			statuses: []int{500},
+			steps: []step{
+				{wantStatus: 500},
+				{wantStatus: 500},
+				{wantOpen: true},
+				{wantOpen: true},
+			},
+			wantHits: 2,
+		},
+		{
+			name:     "success resets failures",
+			statuses: []int{500, 200, 500, 200},
+			steps: []step{
+				{wantStatus: 500},
+				{wantStatus: 200},
+				{wantStatus: 500},
+				{wantStatus: 200},
+			},
+			wantHits: 4,
+		},
+		{
+			name:     "half open request that succeeds closes circuit",
+			statuses: []int{500, 500, 200},
+			steps: []step{
+				{wantStatus: 500},
+				{wantStatus: 500},
+				{wantOpen: true},
+				{wait: cooldown * 2, wantStatus: 200},
+				{wantStatus: 200},
+			},
+			wantHits: 4,
+		},
+		{
+			name:     "half open request that fails opens circuit again",
+			statuses: []int{500},
+			steps: []step{
+				{wantStatus: 500},
+				{wantStatus: 500},
+				{wait: cooldown * 2, wantStatus: 500},
+				{wantOpen: true},
+			},
+			wantHits: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := newStatusServer(t, tt.statuses...)
+			s := New("test", Options{BreakerThreshold: 2, BreakerCooldown: cooldown})
+			for i, st := range tt.steps {
+				time.Sleep(st.wait)
+				res, err := s.Get(srv.URL)
+				if st.wantOpen {
+					if !errors.Is(err, ErrCircuitOpen) {
+						t.Fatalf("step %d: error = %v, want ErrCircuitOpen", i, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				res.Body.Close()
+				if res.StatusCode != st.wantStatus {
+					t.Errorf("step %d: status = %d, want %d", i, res.StatusCode, st.wantStatus)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
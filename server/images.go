@@ -20,9 +20,13 @@ import (
 	"time"
 
 	"github.com/buckket/go-blurhash"
+	"github.com/sbondCo/Watcharr/httpc"
 	"gorm.io/gorm"
 )
 
+// Used for downloading images (ex, posters).
+var imagesHttp = httpc.New("images", httpc.Options{Timeout: 60 * time.Second, MaxRetries: 2})
+
 // For user uploaded images
 type Image struct {
 	ID        uint      `gorm:"primarykey" json:"-"`
@@ -126,7 +130,7 @@ func downloadAndInsertImage(db *gorm.DB, url string, imgSubPath string) (Image,
 	slog.Debug("Attempting to download image", "url", url)
 
 	// Get the data
-	resp, err := http.Get(url)
+	resp, err := imagesHttp.Get(url)
 	if err != nil {
 		return Image{}, err
 	}
@@ -72,7 +72,7 @@ func importContent(db *gorm.DB, userId uint, ar ImportRequest) (ImportResponse,
 		}
 	}
 	// tmdbId not passed.. search for the content by name.
-	sr, err := searchContent(ar.Name, 1)
+	sr, err := searchContent(ar.Name, 1, tmdbDefaultLanguage)
 	if err != nil {
 		slog.Error("import: content search failed", "error", err)
 		return ImportResponse{}, errors.New("Content search failed")
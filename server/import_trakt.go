@@ -13,9 +13,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/sbondCo/Watcharr/httpc"
 	"gorm.io/gorm"
 )
 
+var traktHttp = httpc.New("trakt", httpc.Options{Timeout: 30 * time.Second, MaxRetries: 3})
+
 type TraktImportRequest struct {
 	// Username of public trakt user to import from.
 	Username string `json:"username" binding:"required"`
@@ -412,7 +415,7 @@ func traktAPIRequest(ep string, p map[string]string, resp interface{}) (http.Hea
 	req.Header.Add("trakt-api-key", "c481cb044dcd58d83f3fde113741d1e28d19c1bef1bcbfcb9acedee222f3a673")
 	req.Header.Add("trakt-api-version", "2")
 	req.Header.Add("Content-type", "application/json")
-	res, err := http.DefaultClient.Do(req)
+	res, err := traktHttp.Do(req)
 	if err != nil {
 		return map[string][]string{}, err
 	}
@@ -11,8 +11,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sbondCo/Watcharr/httpc"
 )
 
+var jellyfinHttp = httpc.New("jellyfin", httpc.Options{Timeout: 30 * time.Second, MaxRetries: 2})
+
 type JellyfinItemSearchResponse struct {
 	Items []JellyfinItems `json:"Items"`
 }
@@ -97,7 +100,6 @@ func jellyfinAPIRequest(method string, ep string, p map[string]string, username
 	base.RawQuery = params.Encode()
 
 	// Run get request
-	client := &http.Client{}
 	req, err := http.NewRequest(method, base.String(), bytes.NewBuffer([]byte{}))
 	if err != nil {
 		slog.Error("Creating request to jellyfin failed", "error", err)
@@ -109,7 +111,7 @@ func jellyfinAPIRequest(method string, ep string, p map[string]string, username
 		authHeader += ", Token=\"" + userToken + "\""
 	}
 	req.Header.Add("X-Emby-Authorization", authHeader)
-	res, err := client.Do(req)
+	res, err := jellyfinHttp.Do(req)
 	if err != nil {
 		slog.Error("making request to jellyfin for auth failed", "error", err)
 		return errors.New("request failed")
@@ -2,8 +2,11 @@ package main
 
 import (
 	"log/slog"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
 )
 
 // Location middleware
@@ -29,3 +32,34 @@ func WhereaboutsRequired() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Language middleware for content metadata.
+// Uses the `lang` query param if passed, otherwise the users
+// language setting, falling back to server default.
+// Requires AuthRequired to be ran before.
+func LanguageRequired(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := c.Query("lang")
+		if lang != "" {
+			if _, err := language.Parse(lang); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: "invalid language"})
+				return
+			}
+		} else {
+			var userLang *string
+			if userId, ok := c.Get("userId"); ok {
+				if res := db.Model(&User{}).Select("language").Where("id = ?", userId).Scan(&userLang); res.Error != nil {
+					slog.Error("LanguageRequired: Failed to get users language", "error", res.Error)
+				}
+			}
+			lang = getUserLanguage(userLang)
+			// Add to the query so page caches are kept per language.
+			q := c.Request.URL.Query()
+			q.Set("lang", lang)
+			c.Request.URL.RawQuery = q.Encode()
+		}
+		slog.Debug("LanguageRequired: middleware hit", "lang", lang)
+		c.Set("userLanguage", lang)
+		c.Next()
+	}
+}
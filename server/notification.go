@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+var (
+	NOTIFICATION_ARR_REQUEST_APPROVED  NotificationType = "ARR_REQUEST_APPROVED"
+	NOTIFICATION_ARR_REQUEST_DENIED    NotificationType = "ARR_REQUEST_DENIED"
+	NOTIFICATION_ARR_REQUEST_AVAILABLE NotificationType = "ARR_REQUEST_AVAILABLE"
+	NOTIFICATION_ARR_REQUEST_COMMENT   NotificationType = "ARR_REQUEST_COMMENT"
+	NOTIFICATION_NEW_EPISODE           NotificationType = "NEW_EPISODE"
+	NOTIFICATION_NEW_SEASON            NotificationType = "NEW_SEASON"
+	NOTIFICATION_MOVIE_RELEASED        NotificationType = "MOVIE_RELEASED"
+	NOTIFICATION_GOAL_MILESTONE        NotificationType = "GOAL_MILESTONE"
+)
+
+// Notifications are stored per user, so they can be
+// viewed (and marked as read) next time they use the client.
+type Notification struct {
+	GormModel
+	// ID of user this notification is for.
+	UserID uint `json:"-" gorm:"not null"`
+	// Type of notification.
+	Type NotificationType `json:"type" gorm:"not null"`
+	// Human readable message to show the user.
+	Message string `json:"message" gorm:"not null"`
+	// Holds custom data related to this notification
+	// (ex, the id of the request it is about).
+	Data string `json:"data"`
+	// Content this notification relates to, if any.
+	ContentID *int     `json:"-"`
+	Content   *Content `json:"content,omitempty"`
+	// If the user has read this notification.
+	Read bool `json:"read" gorm:"default:false;not null"`
+}
+
+type NotificationAddRequest struct {
+	Type      NotificationType
+	Message   string
+	Data      string
+	ContentID *int
+}
+
+func getNotifications(db *gorm.DB, userId uint, onlyUnread bool) ([]Notification, error) {
+	notifications := new([]Notification)
+	q := db.Model(&Notification{}).Preload("Content").Where("user_id = ?", userId)
+	if onlyUnread {
+		q = q.Where("read = ?", false)
+	}
+	res := q.Order("created_at DESC").Find(&notifications)
+	if res.Error != nil {
+		slog.Error("getNotifications: Failed getting notifications from database", "error", res.Error.Error())
+		return []Notification{}, errors.New("failed getting notifications")
+	}
+	return *notifications, nil
+}
+
+func addNotification(db *gorm.DB, userId uint, nr NotificationAddRequest) (Notification, error) {
+	if userId == 0 {
+		return Notification{}, errors.New("userId must be set to add a notification")
+	}
+	notification := Notification{UserID: userId, Type: nr.Type, Message: nr.Message, Data: nr.Data, ContentID: nr.ContentID}
+	res := db.Create(&notification)
+	if res.Error != nil {
+		slog.Error("addNotification: Error adding notification to database", "error", res.Error.Error())
+		return Notification{}, errors.New("failed adding new notification to database")
+	}
+	slog.Debug("addNotification: Added notification", "user_id", userId, "type", nr.Type)
+	go dispatchNotification(db, notification)
+	return notification, nil
+}
+
+// Mark one of our notifications as read.
+func readNotification(db *gorm.DB, userId uint, id uint) error {
+	res := db.Model(&Notification{}).Where("id = ? AND user_id = ?", id, userId).Update("read", true)
+	if res.Error != nil {
+		slog.Error("readNotification: Error updating notification in database", "error", res.Error.Error())
+		return errors.New("failed updating notification")
+	}
+	if res.RowsAffected == 0 {
+		slog.Error("readNotification: Zero rows affected.. notification likely does not exist", "id", id, "user_id", userId)
+		return errors.New("notification does not exist")
+	}
+	return nil
+}
+
+// Mark all of our notifications as read.
+func readAllNotifications(db *gorm.DB, userId uint) error {
+	res := db.Model(&Notification{}).Where("user_id = ? AND read = ?", userId, false).Update("read", true)
+	if res.Error != nil {
+		slog.Error("readAllNotifications: Error updating notifications in database", "error", res.Error.Error())
+		return errors.New("failed updating notifications")
+	}
+	return nil
+}
+
+func deleteNotification(db *gorm.DB, userId uint, id uint) error {
+	res := db.Where("id = ? AND user_id = ?", id, userId).Delete(&Notification{})
+	if res.Error != nil {
+		slog.Error("deleteNotification: Error deleting notification from database", "error", res.Error.Error())
+		return errors.New("failed deleting notification")
+	}
+	if res.RowsAffected == 0 {
+		slog.Error("deleteNotification: Zero rows affected.. notification likely does not exist", "id", id, "user_id", userId)
+		return errors.New("notification does not exist")
+	}
+	return nil
+}
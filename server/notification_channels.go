@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/sbondCo/Watcharr/httpc"
+	"gorm.io/gorm"
+)
+
+// Channel urls are set by users, so only allow requests to public addresses,
+// otherwise they could be used to reach services on our network.
+var notifyHttp = httpc.New("notifications", httpc.Options{
+	Timeout:    15 * time.Second,
+	MaxRetries: 2,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			// Checked after resolving, so hostnames (and redirects) can't point us elsewhere.
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+					return errNotificationChannelAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+})
+
+var errNotificationChannelAddress = errors.New("notification channel url must be a public address")
+
+type NotificationChannelType string
+
+var (
+	// POST notification as json to any url.
+	NOTIFICATION_CHANNEL_WEBHOOK NotificationChannelType = "WEBHOOK"
+	// Discord channel webhook url.
+	NOTIFICATION_CHANNEL_DISCORD NotificationChannelType = "DISCORD"
+	// ntfy topic url (eg: https://ntfy.sh/mytopic).
+	NOTIFICATION_CHANNEL_NTFY NotificationChannelType = "NTFY"
+)
+
+// Outbound channel a users notifications are also sent to.
+type NotificationChannel struct {
+	GormModel
+	UserID  uint                    `json:"-" gorm:"not null"`
+	Name    string                  `json:"name" gorm:"not null"`
+	Type    NotificationChannelType `json:"type" gorm:"not null"`
+	URL     string                  `json:"url" gorm:"not null"`
+	Enabled bool                    `json:"enabled" gorm:"default:true;not null"`
+}
+
+type NotificationChannelRequest struct {
+	Name    string                  `json:"name" binding:"required,max=100"`
+	Type    NotificationChannelType `json:"type" binding:"required,oneof=WEBHOOK DISCORD NTFY"`
+	URL     string                  `json:"url" binding:"required,url,max=2000"`
+	Enabled *bool                   `json:"enabled"`
+}
+
+// Body sent to WEBHOOK channels.
+type NotificationWebhookBody struct {
+	Type      NotificationType `json:"type"`
+	Message   string           `json:"message"`
+	Data      string           `json:"data,omitempty"`
+	Content   *Content         `json:"content,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// If ip is publicly routable (not loopback, private, link local, etc).
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast()
+}
+
+// Check channel url is one we are happy to send requests to.
+func validateNotificationChannelURL(u string) error {
+	pu, err := url.Parse(u)
+	if err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Hostname() == "" {
+		return errors.New("notification channel url must be a http or https url")
+	}
+	if ip := net.ParseIP(pu.Hostname()); ip != nil && !isPublicIP(ip) {
+		return errNotificationChannelAddress
+	}
+	return nil
+}
+
+func getNotificationChannels(db *gorm.DB, userId uint) ([]NotificationChannel, error) {
+	channels := []NotificationChannel{}
+	if res := db.Where("user_id = ?", userId).Find(&channels); res.Error != nil {
+		slog.Error("getNotificationChannels: Failed to get channels", "user_id", userId, "error", res.Error)
+		return []NotificationChannel{}, errors.New("failed to get notification channels")
+	}
+	return channels, nil
+}
+
+func addNotificationChannel(db *gorm.DB, userId uint, cr NotificationChannelRequest) (NotificationChannel, error) {
+	ch := NotificationChannel{UserID: userId, Name: cr.Name, Type: cr.Type, URL: cr.URL, Enabled: true}
+	if cr.Enabled != nil {
+		ch.Enabled = *cr.Enabled
+	}
+	if res := db.Create(&ch); res.Error != nil {
+		slog.Error("addNotificationChannel: Failed to create channel", "user_id", userId, "error", res.Error)
+		return NotificationChannel{}, errors.New("failed to add notification channel")
+	}
+	// Create ignores false when there is a default.
+	if !ch.Enabled {
+		db.Model(&ch).Update("enabled", false)
+	}
+	return ch, nil
+}
+
+func updateNotificationChannel(db *gorm.DB, userId uint, id uint, cr NotificationChannelRequest) (NotificationChannel, error) {
+	ch := new(NotificationChannel)
+	if res := db.Where("id = ? AND user_id = ?", id, userId).Take(&ch); res.Error != nil {
+		return NotificationChannel{}, errors.New("notification channel does not exist")
+	}
+	ch.Name = cr.Name
+	ch.Type = cr.Type
+	ch.URL = cr.URL
+	if cr.Enabled != nil {
+		ch.Enabled = *cr.Enabled
+	}
+	if res := db.Save(&ch); res.Error != nil {
+		slog.Error("updateNotificationChannel: Failed to update channel", "id", id, "error", res.Error)
+		return NotificationChannel{}, errors.New("failed to update notification channel")
+	}
+	return *ch, nil
+}
+
+func deleteNotificationChannel(db *gorm.DB, userId uint, id uint) error {
+	res := db.Where("id = ? AND user_id = ?", id, userId).Delete(&NotificationChannel{})
+	if res.Error != nil {
+		slog.Error("deleteNotificationChannel: Failed to delete channel", "id", id, "error", res.Error)
+		return errors.New("failed to delete notification channel")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("notification channel does not exist")
+	}
+	return nil
+}
+
+// Send a test notification to a channel.
+func testNotificationChannel(db *gorm.DB, userId uint, id uint) error {
+	ch := new(NotificationChannel)
+	if res := db.Where("id = ? AND user_id = ?", id, userId).Take(&ch); res.Error != nil {
+		return errors.New("notification channel does not exist")
+	}
+	err := sendToNotificationChannel(*ch, Notification{Type: "TEST", Message: "Test notification from Watcharr."})
+	if err != nil {
+		slog.Error("testNotificationChannel: Failed to send", "id", id, "error", err)
+		if errors.Is(err, errNotificationChannelAddress) {
+			return errNotificationChannelAddress
+		}
+		return errors.New("failed to send test notification")
+	}
+	return nil
+}
+
+// Send notification to all of the users enabled channels.
+func dispatchNotification(db *gorm.DB, n Notification) {
+	channels := []NotificationChannel{}
+	if res := db.Where("user_id = ? AND enabled = ?", n.UserID, true).Find(&channels); res.Error != nil {
+		slog.Error("dispatchNotification: Failed to get channels", "user_id", n.UserID, "error", res.Error)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+	if n.ContentID != nil && n.Content == nil {
+		c := new(Content)
+		if res := db.Where("id = ?", *n.ContentID).Take(&c); res.Error == nil {
+			n.Content = c
+		}
+	}
+	for _, ch := range channels {
+		if err := sendToNotificationChannel(ch, n); err != nil {
+			slog.Error("dispatchNotification: Failed to send to channel", "channel_id", ch.ID, "type", ch.Type, "error", err)
+		}
+	}
+}
+
+func sendToNotificationChannel(ch NotificationChannel, n Notification) error {
+	var req *http.Request
+	var err error
+	switch ch.Type {
+	case NOTIFICATION_CHANNEL_WEBHOOK:
+		body, _ := json.Marshal(NotificationWebhookBody{Type: n.Type, Message: n.Message, Data: n.Data, Content: n.Content, CreatedAt: n.CreatedAt})
+		req, err = http.NewRequest(http.MethodPost, ch.URL, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	case NOTIFICATION_CHANNEL_DISCORD:
+		body, _ := json.Marshal(map[string]string{"content": n.Message})
+		req, err = http.NewRequest(http.MethodPost, ch.URL, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	case NOTIFICATION_CHANNEL_NTFY:
+		req, err = http.NewRequest(http.MethodPost, ch.URL, bytes.NewReader([]byte(n.Message)))
+		if err == nil {
+			req.Header.Set("Title", "Watcharr")
+		}
+	default:
+		return errors.New("unknown channel type")
+	}
+	if err != nil {
+		return err
+	}
+	res, err := notifyHttp.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("channel responded with status %d", res.StatusCode)
+	}
+	return nil
+}
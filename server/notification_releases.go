@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How far back releases are looked for, so runs that were
+// missed (server down) still notify. Notifications are only
+// ever sent once per release, so overlap is fine.
+const releaseNotifyWindow = 3 * 24 * time.Hour
+
+// Show statuses (from TMDB) that can still get new episodes.
+var returningShowStatuses = []string{"Returning Series", "In Production", "Planned"}
+
+type releaseNotificationData struct {
+	Season      int    `json:"season,omitempty"`
+	Episode     int    `json:"episode,omitempty"`
+	ReleaseType string `json:"releaseType,omitempty"`
+}
+
+// Check for newly aired episodes and released movies and notify users that follow them.
+func checkNewReleases(db *gorm.DB) {
+	since := time.Now().Add(-releaseNotifyWindow)
+	checkNewEpisodes(db, since)
+	checkNewMovieReleases(db, since)
+}
+
+// Notify users watching (or finished with returning) shows about new episodes.
+func checkNewEpisodes(db *gorm.DB, since time.Time) {
+	rows := []struct {
+		Episode
+		Title     string
+		UserID    uint
+		WatchedID uint
+	}{}
+	res := db.Model(&Episode{}).
+		Select("episodes.*, contents.title, watcheds.user_id, watcheds.id AS watched_id").
+		Joins("JOIN contents ON contents.id = episodes.content_id").
+		Joins("JOIN watcheds ON watcheds.content_id = contents.id AND watcheds.deleted_at IS NULL").
+		Where("episodes.season_number > 0 AND episodes.air_date >= ? AND episodes.air_date <= ?", since, time.Now()).
+		Where("watcheds.status = ? OR (watcheds.status = ? AND contents.status IN ?)", WATCHING, FINISHED, returningShowStatuses).
+		// Only episodes that aired after the show was added.
+		Where("watcheds.created_at < episodes.air_date").
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("checkNewEpisodes: Failed to get new episodes", "error", res.Error)
+		return
+	}
+	for _, r := range rows {
+		// User may have already watched it.
+		var watchedCount int64
+		db.Model(&WatchedEpisode{}).Where("watched_id = ? AND season_number = ? AND episode_number = ?", r.WatchedID, r.SeasonNumber, r.EpisodeNumber).Count(&watchedCount)
+		if watchedCount > 0 {
+			continue
+		}
+		nt := NOTIFICATION_NEW_EPISODE
+		msg := fmt.Sprintf("%s S%02dE%02d has aired.", r.Title, r.SeasonNumber, r.EpisodeNumber)
+		if r.EpisodeNumber == 1 {
+			nt = NOTIFICATION_NEW_SEASON
+			msg = fmt.Sprintf("%s season %d has started.", r.Title, r.SeasonNumber)
+		}
+		contentId := r.ContentID
+		addReleaseNotification(db, r.UserID, nt, msg, &contentId, releaseNotificationData{Season: r.SeasonNumber, Episode: r.EpisodeNumber})
+	}
+}
+
+// Notify users planning to watch movies when they are released (theatrically or digitally).
+func checkNewMovieReleases(db *gorm.DB, since time.Time) {
+	rows := []struct {
+		Content
+		UserID  uint
+		Country *string
+	}{}
+	res := db.Model(&Content{}).
+		Select("contents.*, watcheds.user_id, users.country").
+		Joins("JOIN watcheds ON watcheds.content_id = contents.id AND watcheds.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = watcheds.user_id").
+		Where("watcheds.status = ? AND contents.type = ?", PLANNED, MOVIE).
+		// Digital releases can be months after the first release.
+		Where("contents.release_date IS NULL OR contents.release_date >= ?", time.Now().AddDate(-1, 0, 0)).
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("checkNewMovieReleases: Failed to get planned movies", "error", res.Error)
+		return
+	}
+	movies := make([]Content, 0, len(rows))
+	for _, r := range rows {
+		movies = append(movies, r.Content)
+	}
+	releaseDates, err := getMovieReleaseDates(db, movies)
+	if err != nil {
+		slog.Error("checkNewMovieReleases: Failed to get release dates", "error", err)
+		return
+	}
+	for _, r := range rows {
+		country := "US"
+		if r.Country != nil && *r.Country != "" {
+			country = *r.Country
+		}
+		for t, name := range calendarReleaseTypes {
+			date, ok := getMovieReleaseDate(releaseDates[r.ID], country, t)
+			if !ok || date.Before(since) || date.After(time.Now()) {
+				continue
+			}
+			contentId := r.ID
+			addReleaseNotification(db, r.UserID, NOTIFICATION_MOVIE_RELEASED, fmt.Sprintf("%s: %s.", r.Title, name), &contentId, releaseNotificationData{ReleaseType: name})
+		}
+	}
+}
+
+// Add release notification for user, if they haven't already had it.
+func addReleaseNotification(db *gorm.DB, userId uint, nt NotificationType, msg string, contentId *int, d releaseNotificationData) {
+	data, _ := json.Marshal(d)
+	var count int64
+	// Unscoped so deleted notifications aren't sent again.
+	res := db.Unscoped().Model(&Notification{}).
+		Where("user_id = ? AND type = ? AND content_id = ? AND data = ?", userId, nt, contentId, string(data)).
+		Count(&count)
+	if res.Error != nil {
+		slog.Error("addReleaseNotification: Failed to check for existing notification", "error", res.Error)
+		return
+	}
+	if count > 0 {
+		return
+	}
+	addNotification(db, userId, NotificationAddRequest{Type: nt, Message: msg, Data: string(data), ContentID: contentId})
+}
@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sbondCo/Watcharr/httpc"
 	"gorm.io/gorm"
 )
 
+var plexHttp = httpc.New("plex", httpc.Options{Timeout: 30 * time.Second, MaxRetries: 2})
+
 type PlexLoginRequest struct {
 	AuthToken        string `json:"token" binding:"required"`
 	ClientIdentifier string `json:"clientIdentifier" binding:"required"`
@@ -369,13 +372,12 @@ func PlexAccessRequired(db *gorm.DB) gin.HandlerFunc {
 }
 
 func getPlexIdentity(host string) (PlexIdentity, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", host+"/identity", nil)
 	if err != nil {
 		return PlexIdentity{}, err
 	}
 	req.Header.Set("Accept", "application/json")
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexIdentity{}, err
 	}
@@ -393,7 +395,6 @@ func getPlexIdentity(host string) (PlexIdentity, error) {
 }
 
 func fetchPlexAccountFromToken(token string) (PlexUser, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", "https://plex.tv/users/account.json", nil)
 	if err != nil {
 		return PlexUser{}, err
@@ -401,7 +402,7 @@ func fetchPlexAccountFromToken(token string) (PlexUser, error) {
 	req.Header.Set("X-Plex-Token", token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexUser{}, err
 	}
@@ -443,14 +444,13 @@ func updateConfigPlexHost(v string) (PlexHostConfigUpdateResponse, error) {
 }
 
 func getPlexLibraries(plexAuth string) (PlexLibrariesResponse, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", Config.PLEX_HOST+"/library/sections", nil)
 	if err != nil {
 		return PlexLibrariesResponse{}, err
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("X-Plex-Token", plexAuth)
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexLibrariesResponse{}, err
 	}
@@ -468,14 +468,13 @@ func getPlexLibraries(plexAuth string) (PlexLibrariesResponse, error) {
 }
 
 func getPlexLibraryItems(plexAuth string, libraryKey string) (PlexLibraryItemsResponse, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", Config.PLEX_HOST+"/library/sections/"+libraryKey+"/all?includeGuids=1", nil)
 	if err != nil {
 		return PlexLibraryItemsResponse{}, err
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("X-Plex-Token", plexAuth)
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexLibraryItemsResponse{}, err
 	}
@@ -493,14 +492,13 @@ func getPlexLibraryItems(plexAuth string, libraryKey string) (PlexLibraryItemsRe
 }
 
 func getPlexLibraryItemSeasons(plexAuth string, ratingKey string) (PlexLibraryItemSeasonsResponse, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", Config.PLEX_HOST+"/library/metadata/"+ratingKey+"/children", nil)
 	if err != nil {
 		return PlexLibraryItemSeasonsResponse{}, err
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("X-Plex-Token", plexAuth)
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexLibraryItemSeasonsResponse{}, err
 	}
@@ -518,14 +516,13 @@ func getPlexLibraryItemSeasons(plexAuth string, ratingKey string) (PlexLibraryIt
 }
 
 func getPlexLibraryItemEpisodes(plexAuth string, ratingKey string) (PlexLibraryItemEpisodesResponse, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", Config.PLEX_HOST+"/library/metadata/"+ratingKey+"/allLeaves", nil)
 	if err != nil {
 		return PlexLibraryItemEpisodesResponse{}, err
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("X-Plex-Token", plexAuth)
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return PlexLibraryItemEpisodesResponse{}, err
 	}
@@ -546,7 +543,6 @@ func getPlexLibraryItemEpisodes(plexAuth string, ratingKey string) (PlexLibraryI
 // so they can authenticate against it for api requests.
 // If no auth token is returned or errored, assume user doesn't have access to home plex server library.
 func getPlexHomeServerAuthToken(plexAuth string, userClientId string) (string, error) {
-	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", "https://clients.plex.tv/api/v2/resources", nil)
 	if err != nil {
 		return "", err
@@ -554,7 +550,7 @@ func getPlexHomeServerAuthToken(plexAuth string, userClientId string) (string, e
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("X-Plex-Token", plexAuth)
 	req.Header.Set("X-Plex-Client-Identifier", userClientId)
-	resp, err := httpClient.Do(req)
+	resp, err := plexHttp.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -15,6 +15,11 @@ type Profile struct {
 	MoviesWatched        int32     `json:"moviesWatched"`
 	MoviesWatchedRuntime uint32    `json:"moviesWatchedRuntime"`
 	ShowsWatchedRuntime  uint32    `json:"showsWatchedRuntime"`
+	// Total views, including rewatches.
+	MoviesViews int32 `json:"moviesViews"`
+	ShowsViews  int32 `json:"showsViews"`
+	// Finished rewatches of single seasons.
+	SeasonViews int32 `json:"seasonViews"`
 }
 
 // Check if content has been previsouly watched by looking for related activity.
@@ -76,15 +81,26 @@ func getProfile(db *gorm.DB, userId uint) (Profile, error) {
 		slog.Error("Profile: Failed to get watched for processing:", "error", res.Error.Error())
 		return Profile{}, errors.New("failed to get watched for processing")
 	}
+	shows := []Content{}
+	for _, w := range *watched {
+		if w.Content != nil && w.Content.Type == SHOW {
+			shows = append(shows, *w.Content)
+		}
+	}
+	episodeRuntimes := getShowsEpisodeRuntime(db, shows)
+	viewCounts := getWatchedViewCounts(db, userId)
+	seasonViews, seasonViewsRuntime := getSeasonViewStats(db, userId)
 	var (
 		showsWatched         int32
 		moviesWatched        int32
 		moviesWatchedRuntime uint32
 		showsWatchedRuntime  uint32
+		moviesViews          int32
+		showsViews           int32
 	)
 	for _, w := range *watched {
 		isFinished := false
-		if w.Status == FINISHED {
+		if w.Status == FINISHED || viewCounts[w.ID] > 0 {
 			isFinished = true
 		} else if *user.IncludePreviouslyWatched && hasBeenPreviouslyWatched(&w.Activity) {
 			// If status is not finished and user has IncludePreviouslyWatched enabled,
@@ -96,20 +112,27 @@ func getProfile(db *gorm.DB, userId uint) (Profile, error) {
 				continue
 			}
 			c := *w.Content
+			// Items finished before views were tracked count as one view.
+			views := uint32(max(viewCounts[w.ID], 1))
 			if c.Type == SHOW {
 				showsWatched++
-				// This aint a science, just a very inaccurate guesstimate.
-				if c.NumberOfEpisodes != 0 {
+				showsViews += int32(views)
+				// Use runtime of episodes when we have them cached.
+				if r, ok := episodeRuntimes[c.ID]; ok {
+					showsWatchedRuntime += r * views
+				} else if c.NumberOfEpisodes != 0 {
+					// This aint a science, just a very inaccurate guesstimate.
 					var showRuntime uint32 = 30
 					if c.Runtime != 0 {
 						showRuntime = c.Runtime
 					}
-					showsWatchedRuntime += showRuntime * c.NumberOfEpisodes
+					showsWatchedRuntime += showRuntime * c.NumberOfEpisodes * views
 					slog.Debug("calcualted", "show", c.Title, "runti", showRuntime*c.NumberOfEpisodes)
 				}
 			} else if c.Type == MOVIE {
 				moviesWatched++
-				moviesWatchedRuntime += c.Runtime
+				moviesViews += int32(views)
+				moviesWatchedRuntime += c.Runtime * views
 			}
 		}
 	}
@@ -118,7 +141,10 @@ func getProfile(db *gorm.DB, userId uint) (Profile, error) {
 		ShowsWatched:         showsWatched,
 		MoviesWatched:        moviesWatched,
 		MoviesWatchedRuntime: moviesWatchedRuntime,
-		ShowsWatchedRuntime:  showsWatchedRuntime,
+		ShowsWatchedRuntime:  showsWatchedRuntime + seasonViewsRuntime,
+		MoviesViews:          moviesViews,
+		ShowsViews:           showsViews,
+		SeasonViews:          seasonViews,
 	}
 	return profile, nil
 }
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Query parameters for stats over a period of time.
+type StatsPeriodQuery struct {
+	Year int `form:"year" binding:"omitempty,min=1900,max=3000"`
+	// Or a date range, `to` is inclusive.
+	From *time.Time `form:"from" time_format:"2006-01-02" time_location:"Local" binding:"required_with=To"`
+	To   *time.Time `form:"to" time_format:"2006-01-02" time_location:"Local" binding:"required_with=From"`
+}
+
+// Get [from, to) for the query, `ok` is false when no period was given.
+func (q StatsPeriodQuery) period() (from time.Time, to time.Time, ok bool) {
+	if q.From != nil && q.To != nil {
+		return *q.From, q.To.AddDate(0, 0, 1), true
+	}
+	if q.Year != 0 {
+		from = time.Date(q.Year, 1, 1, 0, 0, 0, 0, time.Local)
+		return from, from.AddDate(1, 0, 0), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// Watch stats (year in review) for a period.
+// Runtimes are in minutes.
+type StatsReview struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	TotalRuntime    uint32    `json:"totalRuntime"`
+	MoviesRuntime   uint32    `json:"moviesRuntime"`
+	ShowsRuntime    uint32    `json:"showsRuntime"`
+	MoviesWatched   int       `json:"moviesWatched"`
+	MoviesViews     int       `json:"moviesViews"`
+	ShowsWatched    int       `json:"showsWatched"`
+	EpisodesWatched int       `json:"episodesWatched"`
+	// Watching per month (`period` is YYYY-MM).
+	Months []StatsReviewPeriod `json:"months"`
+	// Watching per day of the week, starting from Sunday.
+	Weekdays     []StatsReviewPeriod `json:"weekdays"`
+	TopGenres    []WatchedGenreStat  `json:"topGenres"`
+	TopPeople    []WatchedPersonStat `json:"topPeople"`
+	HighestRated []StatsReviewTitle  `json:"highestRated"`
+	// Most episodes of one show watched in a day.
+	LongestBinge *StatsReviewBinge `json:"longestBinge,omitempty"`
+	// Day with the most watch time (`period` is YYYY-MM-DD).
+	BusiestDay *StatsReviewPeriod `json:"busiestDay,omitempty"`
+	FirstWatch *StatsReviewWatch  `json:"firstWatch,omitempty"`
+	LastWatch  *StatsReviewWatch  `json:"lastWatch,omitempty"`
+}
+
+type StatsReviewPeriod struct {
+	Period   string `json:"period"`
+	Runtime  uint32 `json:"runtime"`
+	Movies   int    `json:"movies"`
+	Episodes int    `json:"episodes"`
+}
+
+type StatsReviewTitle struct {
+	Content *Content `json:"content"`
+	Rating  float64  `json:"rating"`
+}
+
+type StatsReviewBinge struct {
+	Content  *Content `json:"content"`
+	Date     string   `json:"date"`
+	Episodes int      `json:"episodes"`
+	Runtime  uint32   `json:"runtime"`
+}
+
+type StatsReviewWatch struct {
+	Content       *Content  `json:"content"`
+	Date          time.Time `json:"date"`
+	SeasonNumber  int       `json:"seasonNumber,omitempty"`
+	EpisodeNumber int       `json:"episodeNumber,omitempty"`
+}
+
+// A single watch of a movie or episode.
+type userWatch struct {
+	Date          time.Time
+	WatchedID     uint
+	ContentID     int
+	SeasonNumber  int
+	EpisodeNumber int
+	Runtime       uint32
+	isEpisode     bool
+}
+
+// Activity that marks content as finished (for content watched before views were tracked).
+var finishedActivityTypes = []ActivityType{ADDED_WATCHED, STATUS_CHANGED, IMPORTED_WATCHED, IMPORTED_WATCHED_JF, IMPORTED_WATCHED_PLEX, IMPORTED_ADDED_WATCHED, IMPORTED_ADDED_WATCHED_JF, IMPORTED_ADDED_WATCHED_PLEX}
+
+// Get review stats for the year or date range in `rq` (defaults to this year).
+func getStatsReview(db *gorm.DB, userId uint, rq StatsPeriodQuery) (StatsReview, error) {
+	from, to, ok := rq.period()
+	if !ok {
+		from = time.Date(time.Now().Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		to = from.AddDate(1, 0, 0)
+	}
+	if !to.After(from) {
+		return StatsReview{}, errors.New("from date must be before to date")
+	}
+	watches, err := getUserWatches(db, userId, from, to)
+	if err != nil {
+		return StatsReview{}, err
+	}
+
+	review := StatsReview{
+		From:         from,
+		To:           to.AddDate(0, 0, -1),
+		Months:       []StatsReviewPeriod{},
+		Weekdays:     make([]StatsReviewPeriod, 7),
+		TopGenres:    []WatchedGenreStat{},
+		TopPeople:    []WatchedPersonStat{},
+		HighestRated: []StatsReviewTitle{},
+	}
+	for m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.Local); m.Before(to); m = m.AddDate(0, 1, 0) {
+		review.Months = append(review.Months, StatsReviewPeriod{Period: m.Format("2006-01")})
+	}
+	for d := range review.Weekdays {
+		review.Weekdays[d].Period = time.Weekday(d).String()
+	}
+	if len(watches) == 0 {
+		return review, nil
+	}
+
+	days := map[string]*StatsReviewPeriod{}
+	type bingeKey struct {
+		watchedId uint
+		day       string
+	}
+	binges := map[bingeKey]*StatsReviewBinge{}
+	bingeContent := map[bingeKey]int{}
+	movies := map[uint]bool{}
+	shows := map[uint]bool{}
+	contentIds := []int{}
+	for _, w := range watches {
+		if !slices.Contains(contentIds, w.ContentID) {
+			contentIds = append(contentIds, w.ContentID)
+		}
+		day := w.Date.Local().Format("2006-01-02")
+		if days[day] == nil {
+			days[day] = &StatsReviewPeriod{Period: day}
+		}
+		periods := []*StatsReviewPeriod{days[day], &review.Weekdays[w.Date.Local().Weekday()]}
+		if i := monthIndex(review.Months, w.Date.Local().Format("2006-01")); i != -1 {
+			periods = append(periods, &review.Months[i])
+		}
+		for _, p := range periods {
+			p.Runtime += w.Runtime
+			if w.isEpisode {
+				p.Episodes++
+			} else {
+				p.Movies++
+			}
+		}
+		review.TotalRuntime += w.Runtime
+		if w.isEpisode {
+			review.EpisodesWatched++
+			review.ShowsRuntime += w.Runtime
+			shows[w.WatchedID] = true
+			k := bingeKey{w.WatchedID, day}
+			if binges[k] == nil {
+				binges[k] = &StatsReviewBinge{Date: day}
+				bingeContent[k] = w.ContentID
+			}
+			binges[k].Episodes++
+			binges[k].Runtime += w.Runtime
+		} else {
+			review.MoviesViews++
+			review.MoviesRuntime += w.Runtime
+			movies[w.WatchedID] = true
+		}
+	}
+	review.MoviesWatched = len(movies)
+	review.ShowsWatched = len(shows)
+
+	content := map[int]*Content{}
+	contents := []Content{}
+	if res := db.Where("id IN ?", contentIds).Find(&contents); res.Error != nil {
+		slog.Error("getStatsReview: Failed to get content", "user_id", userId, "error", res.Error)
+		return StatsReview{}, errors.New("failed to get content")
+	}
+	for i := range contents {
+		content[contents[i].ID] = &contents[i]
+	}
+
+	for _, d := range days {
+		if review.BusiestDay == nil || d.Runtime > review.BusiestDay.Runtime || (d.Runtime == review.BusiestDay.Runtime && d.Period < review.BusiestDay.Period) {
+			review.BusiestDay = d
+		}
+	}
+	for k, b := range binges {
+		if b.Episodes < 2 {
+			continue
+		}
+		if review.LongestBinge == nil || b.Episodes > review.LongestBinge.Episodes || (b.Episodes == review.LongestBinge.Episodes && b.Date < review.LongestBinge.Date) {
+			b.Content = content[bingeContent[k]]
+			review.LongestBinge = b
+		}
+	}
+	first := watches[0]
+	last := watches[len(watches)-1]
+	review.FirstWatch = &StatsReviewWatch{Content: content[first.ContentID], Date: first.Date, SeasonNumber: first.SeasonNumber, EpisodeNumber: first.EpisodeNumber}
+	review.LastWatch = &StatsReviewWatch{Content: content[last.ContentID], Date: last.Date, SeasonNumber: last.SeasonNumber, EpisodeNumber: last.EpisodeNumber}
+
+	watchedIds := make([]uint, 0, len(movies)+len(shows))
+	for id := range movies {
+		watchedIds = append(watchedIds, id)
+	}
+	for id := range shows {
+		watchedIds = append(watchedIds, id)
+	}
+	watchedQuery := func() *gorm.DB {
+		return db.Model(&Watched{}).Where("watcheds.user_id = ? AND watcheds.id IN ?", userId, watchedIds)
+	}
+	if res := genreStatsQuery(watchedQuery()).Limit(10).Scan(&review.TopGenres); res.Error != nil {
+		slog.Error("getStatsReview: Failed to get genre stats", "user_id", userId, "error", res.Error)
+	}
+	if res := personStatsQuery(watchedQuery(), CREDIT_CAST, "").Limit(10).Scan(&review.TopPeople); res.Error != nil {
+		slog.Error("getStatsReview: Failed to get person stats", "user_id", userId, "error", res.Error)
+	}
+	rated := []Watched{}
+	if res := watchedQuery().Where("watcheds.rating > 0").Order("watcheds.rating DESC").Order("watcheds.updated_at DESC").Limit(10).Find(&rated); res.Error != nil {
+		slog.Error("getStatsReview: Failed to get highest rated", "user_id", userId, "error", res.Error)
+	}
+	for _, w := range rated {
+		if w.ContentID != nil {
+			review.HighestRated = append(review.HighestRated, StatsReviewTitle{Content: content[*w.ContentID], Rating: w.Rating})
+		}
+	}
+	return review, nil
+}
+
+func monthIndex(months []StatsReviewPeriod, month string) int {
+	return slices.IndexFunc(months, func(p StatsReviewPeriod) bool { return p.Period == month })
+}
+
+// Get all movie and episode watches in [from, to), oldest first.
+func getUserWatches(db *gorm.DB, userId uint, from time.Time, to time.Time) ([]userWatch, error) {
+	episodeWatches, err := getEpisodeWatches(db, userId, from, to)
+	if err != nil {
+		return []userWatch{}, err
+	}
+	movieWatches, err := getMovieWatches(db, userId, from, to)
+	if err != nil {
+		return []userWatch{}, err
+	}
+	watches := append(episodeWatches, movieWatches...)
+	slices.SortFunc(watches, func(a, b userWatch) int {
+		return a.Date.Compare(b.Date)
+	})
+	return watches, nil
+}
+
+// Episode plays in [from, to).
+func getEpisodeWatches(db *gorm.DB, userId uint, from time.Time, to time.Time) ([]userWatch, error) {
+	// Use episode runtime when cached, otherwise shows (average) runtime.
+	const runtime = "COALESCE(NULLIF(episodes.runtime, 0), NULLIF(contents.runtime, 0), 30) AS runtime"
+	const episodeJoin = "LEFT JOIN episodes ON episodes.content_id = contents.id AND episodes.season_number = watched_episodes.season_number AND episodes.episode_number = watched_episodes.episode_number"
+	watches := []userWatch{}
+	res := db.Model(&WatchedEpisodePlay{}).
+		Select("watched_episode_plays.watched_at AS date, watcheds.id AS watched_id, watcheds.content_id, watched_episodes.season_number, watched_episodes.episode_number, "+runtime).
+		Joins("JOIN watched_episodes ON watched_episodes.id = watched_episode_plays.watched_episode_id AND watched_episodes.deleted_at IS NULL").
+		Joins("JOIN watcheds ON watcheds.id = watched_episodes.watched_id AND watcheds.deleted_at IS NULL").
+		Joins("JOIN contents ON contents.id = watcheds.content_id").
+		Joins(episodeJoin).
+		Where("watched_episode_plays.user_id = ? AND watched_episode_plays.watched_at >= ? AND watched_episode_plays.watched_at < ?", userId, from, to).
+		Scan(&watches)
+	if res.Error != nil {
+		slog.Error("getEpisodeWatches: Failed to get episode plays", "user_id", userId, "error", res.Error)
+		return []userWatch{}, errors.New("failed to get watched episodes")
+	}
+	for i := range watches {
+		watches[i].isEpisode = true
+	}
+	return watches, nil
+}
+
+// Movie views finished in [from, to). Finished movies without views
+// count as one view on the date of their latest finished activity.
+func getMovieWatches(db *gorm.DB, userId uint, from time.Time, to time.Time) ([]userWatch, error) {
+	return getFinishedWatches(db, userId, string(MOVIE), from, to)
+}
+
+// Views of watched items (of `watchedType`, movie, tv or game) finished in [from, to).
+// Items finished without views count as one view on the date of their latest finished activity.
+func getFinishedWatches(db *gorm.DB, userId uint, watchedType string, from time.Time, to time.Time) ([]userWatch, error) {
+	watches := []userWatch{}
+	res := whereWatchedType(db.Model(&WatchedView{}), watchedType).
+		Select("watched_views.finished_at AS date, watcheds.id AS watched_id, COALESCE(watcheds.content_id, 0) AS content_id, COALESCE(contents.runtime, 0) AS runtime").
+		Joins("JOIN watcheds ON watcheds.id = watched_views.watched_id AND watcheds.deleted_at IS NULL").
+		Joins("LEFT JOIN contents ON contents.id = watcheds.content_id").
+		Where("watched_views.user_id = ? AND watched_views.season_number IS NULL", userId).
+		Where("watched_views.finished_at >= ? AND watched_views.finished_at < ?", from, to).
+		Scan(&watches)
+	if res.Error != nil {
+		slog.Error("getFinishedWatches: Failed to get views", "user_id", userId, "type", watchedType, "error", res.Error)
+		return []userWatch{}, errors.New("failed to get finished items")
+	}
+	legacy := []struct {
+		ID        uint
+		ContentID int
+		Runtime   uint32
+		UpdatedAt time.Time
+	}{}
+	res = whereWatchedType(db.Model(&Watched{}), watchedType).
+		Select("watcheds.id, COALESCE(watcheds.content_id, 0) AS content_id, COALESCE(contents.runtime, 0) AS runtime, watcheds.updated_at").
+		Joins("LEFT JOIN contents ON contents.id = watcheds.content_id").
+		Where("watcheds.user_id = ? AND watcheds.status = ?", userId, FINISHED).
+		Where("NOT EXISTS (SELECT 1 FROM watched_views WHERE watched_views.watched_id = watcheds.id AND watched_views.season_number IS NULL AND watched_views.deleted_at IS NULL)").
+		Scan(&legacy)
+	if res.Error != nil {
+		slog.Error("getFinishedWatches: Failed to get finished items", "user_id", userId, "type", watchedType, "error", res.Error)
+		return []userWatch{}, errors.New("failed to get finished items")
+	}
+	if len(legacy) == 0 {
+		return watches, nil
+	}
+	ids := make([]uint, len(legacy))
+	for i, l := range legacy {
+		ids[i] = l.ID
+	}
+	activity := []Activity{}
+	if res := db.Where("watched_id IN ? AND type IN ?", ids, finishedActivityTypes).Find(&activity); res.Error != nil {
+		slog.Error("getFinishedWatches: Failed to get activity", "user_id", userId, "error", res.Error)
+		return []userWatch{}, errors.New("failed to get finished items")
+	}
+	finishedAt := map[uint]time.Time{}
+	for _, a := range activity {
+		if !isFinishedActivity(a) {
+			continue
+		}
+		date := a.CreatedAt
+		if a.CustomDate != nil {
+			date = *a.CustomDate
+		}
+		if date.After(finishedAt[a.WatchedID]) {
+			finishedAt[a.WatchedID] = date
+		}
+	}
+	for _, l := range legacy {
+		date, ok := finishedAt[l.ID]
+		if !ok {
+			date = l.UpdatedAt
+		}
+		if date.Before(from) || !date.Before(to) {
+			continue
+		}
+		watches = append(watches, userWatch{Date: date, WatchedID: l.ID, ContentID: l.ContentID, Runtime: l.Runtime})
+	}
+	return watches, nil
+}
+
+// Filter watched items (joined with contents) by type (movie, tv or game).
+func whereWatchedType(q *gorm.DB, watchedType string) *gorm.DB {
+	if watchedType == "game" {
+		return q.Where("watcheds.game_id IS NOT NULL")
+	}
+	return q.Where("contents.type = ?", watchedType)
+}
+
+func isFinishedActivity(a Activity) bool {
+	switch a.Type {
+	case STATUS_CHANGED:
+		return a.Data == string(FINISHED)
+	case IMPORTED_ADDED_WATCHED, IMPORTED_ADDED_WATCHED_JF, IMPORTED_ADDED_WATCHED_PLEX:
+		return true
+	default:
+		return strings.Contains(a.Data, `"status":"`+string(FINISHED)+`"`)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Watching per day, for drawing a heatmap.
+type StatsHeatmap struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Every day in the period (oldest first).
+	Days []StatsHeatmapDay `json:"days"`
+	// Highest count of a day, for scaling.
+	MaxCount int `json:"maxCount"`
+}
+
+type StatsHeatmapDay struct {
+	Date string `json:"date"`
+	// Movies and episodes watched.
+	Count   int    `json:"count"`
+	Runtime uint32 `json:"runtime"`
+}
+
+// Days in a row with something watched.
+type StatsStreaks struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+	// Last day of the longest streak.
+	LongestEnd string `json:"longestEnd,omitempty"`
+	// Streaks of watching episodes of each show.
+	Shows []StatsShowStreak `json:"shows"`
+}
+
+type StatsShowStreak struct {
+	Content     *Content `json:"content"`
+	Current     int      `json:"current"`
+	Longest     int      `json:"longest"`
+	LongestEnd  string   `json:"longestEnd"`
+	LastWatched string   `json:"lastWatched"`
+}
+
+const heatmapDefaultDays = 365
+
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Get watch counts per day for the year or date range in `rq` (defaults to the last year).
+func getStatsHeatmap(db *gorm.DB, userId uint, rq StatsPeriodQuery) (StatsHeatmap, error) {
+	from, to, ok := rq.period()
+	if !ok {
+		to = startOfDay(time.Now()).AddDate(0, 0, 1)
+		from = to.AddDate(0, 0, -heatmapDefaultDays)
+	}
+	if !to.After(from) {
+		return StatsHeatmap{}, errors.New("from date must be before to date")
+	}
+	if to.Sub(from) > 5*366*24*time.Hour {
+		return StatsHeatmap{}, errors.New("period can't be longer than 5 years")
+	}
+	watches, err := getUserWatches(db, userId, from, to)
+	if err != nil {
+		return StatsHeatmap{}, err
+	}
+	heatmap := StatsHeatmap{From: from, To: to.AddDate(0, 0, -1), Days: []StatsHeatmapDay{}}
+	index := map[string]int{}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		index[date] = len(heatmap.Days)
+		heatmap.Days = append(heatmap.Days, StatsHeatmapDay{Date: date})
+	}
+	for _, w := range watches {
+		i, ok := index[w.Date.Local().Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		heatmap.Days[i].Count++
+		heatmap.Days[i].Runtime += w.Runtime
+		heatmap.MaxCount = max(heatmap.MaxCount, heatmap.Days[i].Count)
+	}
+	return heatmap, nil
+}
+
+// Get users current and longest watching streaks, overall and for each show.
+func getStatsStreaks(db *gorm.DB, userId uint) (StatsStreaks, error) {
+	watches, err := getUserWatches(db, userId, time.Time{}, time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		return StatsStreaks{}, err
+	}
+	today := startOfDay(time.Now())
+	days := []time.Time{}
+	showDays := map[uint][]time.Time{}
+	showContent := map[uint]int{}
+	for _, w := range watches {
+		d := startOfDay(w.Date)
+		if len(days) == 0 || !days[len(days)-1].Equal(d) {
+			days = append(days, d)
+		}
+		if w.isEpisode {
+			sd := showDays[w.WatchedID]
+			if len(sd) == 0 || !sd[len(sd)-1].Equal(d) {
+				showDays[w.WatchedID] = append(sd, d)
+			}
+			showContent[w.WatchedID] = w.ContentID
+		}
+	}
+	streaks := StatsStreaks{Shows: []StatsShowStreak{}}
+	var longestEnd time.Time
+	streaks.Current, streaks.Longest, longestEnd = calcStreaks(days, today)
+	if streaks.Longest > 0 {
+		streaks.LongestEnd = longestEnd.Format("2006-01-02")
+	}
+	if len(showDays) == 0 {
+		return streaks, nil
+	}
+	contentIds := []int{}
+	for _, id := range showContent {
+		contentIds = append(contentIds, id)
+	}
+	contents := []Content{}
+	if res := db.Where("id IN ?", contentIds).Find(&contents); res.Error != nil {
+		slog.Error("getStatsStreaks: Failed to get content", "user_id", userId, "error", res.Error)
+		return StatsStreaks{}, errors.New("failed to get content")
+	}
+	content := map[int]*Content{}
+	for i := range contents {
+		content[contents[i].ID] = &contents[i]
+	}
+	for watchedId, sd := range showDays {
+		current, longest, end := calcStreaks(sd, today)
+		streaks.Shows = append(streaks.Shows, StatsShowStreak{
+			Content:     content[showContent[watchedId]],
+			Current:     current,
+			Longest:     longest,
+			LongestEnd:  end.Format("2006-01-02"),
+			LastWatched: sd[len(sd)-1].Format("2006-01-02"),
+		})
+	}
+	slices.SortFunc(streaks.Shows, func(a, b StatsShowStreak) int {
+		if a.Current != b.Current {
+			return b.Current - a.Current
+		}
+		if a.Longest != b.Longest {
+			return b.Longest - a.Longest
+		}
+		if a.LastWatched > b.LastWatched {
+			return -1
+		} else if a.LastWatched < b.LastWatched {
+			return 1
+		}
+		return 0
+	})
+	return streaks, nil
+}
+
+// Calculate streaks from sorted, unique days.
+// The current streak is still going if the last day is today or yesterday.
+func calcStreaks(days []time.Time, today time.Time) (current int, longest int, longestEnd time.Time) {
+	run := 0
+	for i, d := range days {
+		if i > 0 && d.Equal(days[i-1].AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+			longestEnd = d
+		}
+	}
+	if len(days) > 0 {
+		last := days[len(days)-1]
+		if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			current = run
+		}
+	}
+	return current, longest, longestEnd
+}
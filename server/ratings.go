@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Ratings are bucketed to the nearest half point.
+const ratingBucketStep = 0.5
+
+// Max disagreements returned in a taste comparison.
+const tasteMaxDisagreements = 10
+
+// How a user rates things. Ratings are out of 10.
+type RatingHistogram struct {
+	// Every bucket from 0.5 to 10 (lowest first).
+	Buckets []RatingBucket `json:"buckets"`
+	// Amount of rated items.
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	Median  float64 `json:"median"`
+}
+
+type RatingBucket struct {
+	Rating float64 `json:"rating"`
+	Count  int     `json:"count"`
+}
+
+// How similar a users ratings are to someone they follow,
+// from items they have both rated.
+type TasteComparison struct {
+	User    PublicUser `json:"user"`
+	CoRated int        `json:"coRated"`
+	// Percentage (0-100), from how far apart ratings are.
+	// Not set when nothing has been rated by both users.
+	Compatibility *float64 `json:"compatibility"`
+	// Pearson correlation (-1 to 1) of ratings.
+	// Not set when there isn't enough to compare.
+	Correlation       *float64 `json:"correlation"`
+	AverageDifference float64  `json:"averageDifference"`
+	MyAverage         float64  `json:"myAverage"`
+	TheirAverage      float64  `json:"theirAverage"`
+	// Items rated furthest apart (biggest difference first).
+	Disagreements []TasteDisagreement `json:"disagreements"`
+}
+
+type TasteDisagreement struct {
+	Content     *Content `json:"content,omitempty"`
+	Game        *Game    `json:"game,omitempty"`
+	MyRating    float64  `json:"myRating"`
+	TheirRating float64  `json:"theirRating"`
+	Difference  float64  `json:"difference"`
+}
+
+type coRatedWatched struct {
+	ContentID   *int
+	GameID      *int
+	MyRating    float64
+	TheirRating float64
+}
+
+func roundRating(r float64) float64 {
+	return math.Round(r*100) / 100
+}
+
+// Get users rating distribution.
+// Only for `watchedType` (movie, tv or game) if it's not empty.
+func getRatingHistogram(db *gorm.DB, userId uint, watchedType string) (RatingHistogram, error) {
+	q := db.Model(&Watched{}).
+		Joins("LEFT JOIN contents ON contents.id = watcheds.content_id").
+		Where("watcheds.user_id = ? AND watcheds.rating > 0", userId)
+	if watchedType != "" {
+		q = whereWatchedType(q, watchedType)
+	}
+	ratings := []float64{}
+	if res := q.Pluck("watcheds.rating", &ratings); res.Error != nil {
+		slog.Error("getRatingHistogram: Failed to get ratings", "user_id", userId, "error", res.Error)
+		return RatingHistogram{}, errors.New("failed to get ratings")
+	}
+	steps := int(10 / ratingBucketStep)
+	h := RatingHistogram{Buckets: make([]RatingBucket, steps), Count: len(ratings)}
+	for i := range h.Buckets {
+		h.Buckets[i].Rating = float64(i+1) * ratingBucketStep
+	}
+	if len(ratings) == 0 {
+		return h, nil
+	}
+	sum := 0.0
+	for _, r := range ratings {
+		sum += r
+		i := int(math.Round(r/ratingBucketStep)) - 1
+		h.Buckets[min(max(i, 0), steps-1)].Count++
+	}
+	slices.Sort(ratings)
+	h.Average = roundRating(sum / float64(len(ratings)))
+	if n := len(ratings); n%2 == 0 {
+		h.Median = roundRating((ratings[n/2-1] + ratings[n/2]) / 2)
+	} else {
+		h.Median = ratings[n/2]
+	}
+	return h, nil
+}
+
+// Compare users ratings with a user they follow.
+func getTasteComparison(db *gorm.DB, userId uint, followedUserId uint) (TasteComparison, error) {
+	var f Follow
+	res := db.Where("user_id = ? AND followed_user_id = ?", userId, followedUserId).
+		Preload("FollowedUser", "private = ? AND private_thoughts = ?", 0, 0).
+		Find(&f)
+	if res.Error != nil {
+		slog.Error("getTasteComparison: Error finding follow.", "error", res.Error)
+		return TasteComparison{}, errors.New("failed to find follow")
+	}
+	if res.RowsAffected == 0 {
+		return TasteComparison{}, errors.New("not following")
+	}
+	// Followed user made their account or thoughts private.
+	if f.FollowedUser.ID == 0 {
+		return TasteComparison{}, errors.New("user's ratings are private")
+	}
+	rated := []coRatedWatched{}
+	res = db.Raw(`SELECT a.content_id, a.game_id, a.rating AS my_rating, b.rating AS their_rating
+		FROM watcheds a
+		JOIN watcheds b ON (a.content_id = b.content_id OR a.game_id = b.game_id)
+		WHERE a.user_id = ? AND b.user_id = ?
+			AND a.rating > 0 AND b.rating > 0
+			AND a.deleted_at IS NULL AND b.deleted_at IS NULL`, userId, followedUserId).
+		Scan(&rated)
+	if res.Error != nil {
+		slog.Error("getTasteComparison: Failed to get co-rated watched items", "user_id", userId, "followed_user_id", followedUserId, "error", res.Error)
+		return TasteComparison{}, errors.New("failed to get ratings")
+	}
+	tc := TasteComparison{User: f.FollowedUser.GetSafe(), CoRated: len(rated), Disagreements: []TasteDisagreement{}}
+	if len(rated) == 0 {
+		return tc, nil
+	}
+	n := float64(len(rated))
+	var mySum, theirSum, diffSum float64
+	for _, r := range rated {
+		mySum += r.MyRating
+		theirSum += r.TheirRating
+		diffSum += math.Abs(r.MyRating - r.TheirRating)
+	}
+	myAvg, theirAvg := mySum/n, theirSum/n
+	tc.MyAverage = roundRating(myAvg)
+	tc.TheirAverage = roundRating(theirAvg)
+	tc.AverageDifference = roundRating(diffSum / n)
+	compat := roundRating(max(0, 100-(diffSum/n)*10))
+	tc.Compatibility = &compat
+	var cov, myVar, theirVar float64
+	for _, r := range rated {
+		cov += (r.MyRating - myAvg) * (r.TheirRating - theirAvg)
+		myVar += (r.MyRating - myAvg) * (r.MyRating - myAvg)
+		theirVar += (r.TheirRating - theirAvg) * (r.TheirRating - theirAvg)
+	}
+	if len(rated) > 1 && myVar > 0 && theirVar > 0 {
+		corr := math.Round(cov/math.Sqrt(myVar*theirVar)*1000) / 1000
+		tc.Correlation = &corr
+	}
+	slices.SortStableFunc(rated, func(a, b coRatedWatched) int {
+		diffA, diffB := math.Abs(a.MyRating-a.TheirRating), math.Abs(b.MyRating-b.TheirRating)
+		if diffA > diffB {
+			return -1
+		} else if diffA < diffB {
+			return 1
+		}
+		return 0
+	})
+	contentIds := []int{}
+	gameIds := []int{}
+	for _, r := range rated[:min(len(rated), tasteMaxDisagreements)] {
+		if r.MyRating == r.TheirRating {
+			break
+		}
+		if r.GameID != nil {
+			gameIds = append(gameIds, *r.GameID)
+		} else if r.ContentID != nil {
+			contentIds = append(contentIds, *r.ContentID)
+		}
+		tc.Disagreements = append(tc.Disagreements, TasteDisagreement{
+			MyRating:    r.MyRating,
+			TheirRating: r.TheirRating,
+			Difference:  roundRating(math.Abs(r.MyRating - r.TheirRating)),
+		})
+	}
+	content := map[int]*Content{}
+	if len(contentIds) > 0 {
+		contents := []Content{}
+		if res := db.Where("id IN ?", contentIds).Find(&contents); res.Error != nil {
+			slog.Error("getTasteComparison: Failed to get content", "error", res.Error)
+			return TasteComparison{}, errors.New("failed to get content")
+		}
+		for i := range contents {
+			content[contents[i].ID] = &contents[i]
+		}
+	}
+	game := map[int]*Game{}
+	if len(gameIds) > 0 {
+		games := []Game{}
+		if res := db.Where("id IN ?", gameIds).Find(&games); res.Error != nil {
+			slog.Error("getTasteComparison: Failed to get games", "error", res.Error)
+			return TasteComparison{}, errors.New("failed to get games")
+		}
+		for i := range games {
+			game[games[i].ID] = &games[i]
+		}
+	}
+	for i := range tc.Disagreements {
+		r := rated[i]
+		if r.GameID != nil {
+			tc.Disagreements[i].Game = game[*r.GameID]
+		} else if r.ContentID != nil {
+			tc.Disagreements[i].Content = content[*r.ContentID]
+		}
+	}
+	return tc, nil
+}
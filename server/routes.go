@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cache"
@@ -11,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sbondCo/Watcharr/arr"
 	"github.com/sbondCo/Watcharr/game"
+	"github.com/sbondCo/Watcharr/httpc"
 	"gorm.io/gorm"
 )
 
@@ -75,11 +79,11 @@ func (b *BaseRouter) addSetupRoutes() {
 }
 
 func (b *BaseRouter) addContentRoutes() {
-	content := b.rg.Group("/content").Use(AuthRequired(nil))
+	content := b.rg.Group("/content").Use(AuthRequired(nil), LanguageRequired(b.db))
 	exp := time.Hour * 24
 
 	// Search for content
-	content.GET("/search/multi/:query", cache.CachePage(b.ms, exp, func(c *gin.Context) {
+	content.GET("/search/multi/:query", WithArrAvailability(b.db, ""), cache.CachePage(b.ms, exp, func(c *gin.Context) {
 		if c.Param("query") == "" {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "a query was not provided"})
 			return
@@ -94,7 +98,7 @@ func (b *BaseRouter) addContentRoutes() {
 			}
 			pageNum = num
 		}
-		content, err := searchContent(c.Param("query"), pageNum)
+		content, err := searchContent(c.Param("query"), pageNum, c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -103,7 +107,7 @@ func (b *BaseRouter) addContentRoutes() {
 	}))
 
 	// Search for movies
-	content.GET("/search/movie/:query", cache.CachePage(b.ms, exp, func(c *gin.Context) {
+	content.GET("/search/movie/:query", WithArrAvailability(b.db, ""), cache.CachePage(b.ms, exp, func(c *gin.Context) {
 		if c.Param("query") == "" {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "a query was not provided"})
 			return
@@ -118,7 +122,7 @@ func (b *BaseRouter) addContentRoutes() {
 			}
 			pageNum = num
 		}
-		content, err := searchMovies(c.Param("query"), pageNum)
+		content, err := searchMovies(c.Param("query"), pageNum, c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -127,7 +131,7 @@ func (b *BaseRouter) addContentRoutes() {
 	}))
 
 	// Search for shows
-	content.GET("/search/tv/:query", cache.CachePage(b.ms, exp, func(c *gin.Context) {
+	content.GET("/search/tv/:query", WithArrAvailability(b.db, ""), cache.CachePage(b.ms, exp, func(c *gin.Context) {
 		if c.Param("query") == "" {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "a query was not provided"})
 			return
@@ -142,7 +146,7 @@ func (b *BaseRouter) addContentRoutes() {
 			}
 			pageNum = num
 		}
-		content, err := searchTv(c.Param("query"), pageNum)
+		content, err := searchTv(c.Param("query"), pageNum, c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -166,7 +170,7 @@ func (b *BaseRouter) addContentRoutes() {
 			}
 			pageNum = num
 		}
-		content, err := searchPeople(c.Param("query"), pageNum)
+		content, err := searchPeople(c.Param("query"), pageNum, c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -175,12 +179,12 @@ func (b *BaseRouter) addContentRoutes() {
 	}))
 
 	// Get movie details (for movie page)
-	content.GET("/movie/:id", WhereaboutsRequired(), cache.CachePage(b.ms, exp, func(c *gin.Context) {
+	content.GET("/movie/:id", WhereaboutsRequired(), WithArrAvailability(b.db, MOVIE), cache.CachePage(b.ms, exp, func(c *gin.Context) {
 		if c.Param("id") == "" {
 			c.Status(400)
 			return
 		}
-		content, err := movieDetails(b.db, c.Param("id"), c.MustGet("userCountry").(string), map[string]string{"append_to_response": "videos,watch/providers,similar"})
+		content, err := movieDetails(b.db, c.Param("id"), c.MustGet("userCountry").(string), map[string]string{"append_to_response": "videos,watch/providers,similar,release_dates", "language": c.GetString("userLanguage")})
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -194,7 +198,7 @@ func (b *BaseRouter) addContentRoutes() {
 			c.Status(400)
 			return
 		}
-		content, err := movieCredits(c.Param("id"))
+		content, err := movieCredits(b.db, c.Param("id"), c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -203,12 +207,12 @@ func (b *BaseRouter) addContentRoutes() {
 	}))
 
 	// Get tv details (for tv page)
-	content.GET("/tv/:id", WhereaboutsRequired(), cache.CachePage(b.ms, exp, func(c *gin.Context) {
+	content.GET("/tv/:id", WhereaboutsRequired(), WithArrAvailability(b.db, SHOW), cache.CachePage(b.ms, exp, func(c *gin.Context) {
 		if c.Param("id") == "" {
 			c.Status(400)
 			return
 		}
-		content, err := tvDetails(b.db, c.Param("id"), c.MustGet("userCountry").(string), map[string]string{"append_to_response": "videos,watch/providers,similar,external_ids,keywords"})
+		content, err := tvDetails(b.db, c.Param("id"), c.MustGet("userCountry").(string), map[string]string{"append_to_response": "videos,watch/providers,similar,external_ids,keywords", "language": c.GetString("userLanguage")})
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -222,7 +226,7 @@ func (b *BaseRouter) addContentRoutes() {
 			c.Status(400)
 			return
 		}
-		content, err := tvCredits(c.Param("id"))
+		content, err := tvCredits(b.db, c.Param("id"), c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -237,7 +241,7 @@ func (b *BaseRouter) addContentRoutes() {
 			c.Status(400)
 			return
 		}
-		content, err := seasonDetails(c.Param("id"), c.Param("num"))
+		content, err := seasonDetails(b.db, c.Param("id"), c.Param("num"), c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -273,7 +277,7 @@ func (b *BaseRouter) addContentRoutes() {
 			c.Status(400)
 			return
 		}
-		content, err := personDetails(c.Param("id"))
+		content, err := personDetails(c.Param("id"), c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -287,7 +291,7 @@ func (b *BaseRouter) addContentRoutes() {
 			c.Status(400)
 			return
 		}
-		content, err := personCredits(c.Param("id"))
+		content, err := personCredits(c.Param("id"), c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -297,7 +301,7 @@ func (b *BaseRouter) addContentRoutes() {
 
 	// Discover movies
 	content.GET("/discover/movies", cache.CachePage(b.ms, exp, func(c *gin.Context) {
-		content, err := discoverMovies()
+		content, err := discoverMovies(c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -307,7 +311,7 @@ func (b *BaseRouter) addContentRoutes() {
 
 	// Discover shows
 	content.GET("/discover/tv", cache.CachePage(b.ms, exp, func(c *gin.Context) {
-		content, err := discoverTv()
+		content, err := discoverTv(c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -317,7 +321,7 @@ func (b *BaseRouter) addContentRoutes() {
 
 	// Get all trending (movies, tv, people)
 	content.GET("/trending", cache.CachePage(b.ms, exp, func(c *gin.Context) {
-		content, err := allTrending()
+		content, err := allTrending(c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -327,7 +331,7 @@ func (b *BaseRouter) addContentRoutes() {
 
 	// Upcoming Movies
 	content.GET("/upcoming/movies", cache.CachePage(b.ms, exp, func(c *gin.Context) {
-		content, err := upcomingMovies()
+		content, err := upcomingMovies(c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -337,7 +341,7 @@ func (b *BaseRouter) addContentRoutes() {
 
 	// Upcoming Tv
 	content.GET("/upcoming/tv", cache.CachePage(b.ms, exp, func(c *gin.Context) {
-		content, err := upcomingTv()
+		content, err := upcomingTv(c.GetString("userLanguage"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
@@ -443,12 +447,52 @@ func (b *BaseRouter) addGameRoutes() {
 func (b *BaseRouter) addWatchedRoutes() {
 	watched := b.rg.Group("/watched").Use(AuthRequired(nil))
 
-	watched.GET("", func(c *gin.Context) {
+	watched.GET("", LanguageRequired(b.db), func(c *gin.Context) {
 		userId := c.MustGet("userId").(uint)
-		c.JSON(http.StatusOK, getWatched(b.db, userId))
+		w, err := getWatched(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		localizeWatchedContent(b.db, w, c.GetString("userLanguage"))
+		c.JSON(http.StatusOK, w)
+	})
+
+	// Get a page of the watched list.
+	// Supports filtering, sorting and cursor pagination (see WatchedListQuery).
+	watched.GET("/list", LanguageRequired(b.db), func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var lq WatchedListQuery
+		if err := c.ShouldBindQuery(&lq); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := getWatchedList(b.db, userId, lq)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errInvalidWatchedListCursor) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, ErrorResponse{Error: err.Error()})
+			return
+		}
+		localizeWatchedContent(b.db, response.Results, c.GetString("userLanguage"))
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get next episode to watch for shows being watched.
+	// Supports `finished` query parameter to include finished shows with new episodes.
+	watched.GET("/upnext", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getUpNext(b.db, userId, c.Query("finished") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
 	})
 
-	watched.GET(":id/:username", func(c *gin.Context) {
+	watched.GET(":id/:username", LanguageRequired(b.db), func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 		if err != nil {
 			slog.Error("getPublicWatched route failed to convert id param to uint", "id", id)
@@ -460,6 +504,7 @@ func (b *BaseRouter) addWatchedRoutes() {
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
 			return
 		}
+		localizeWatchedContent(b.db, response, c.GetString("userLanguage"))
 		c.JSON(http.StatusOK, response)
 	})
 
@@ -500,6 +545,64 @@ func (b *BaseRouter) addWatchedRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
+	// Get removed watched items (kept for TRASH_RETENTION_DAYS days).
+	watched.GET("/trash", LanguageRequired(b.db), func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getWatchedTrash(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		localizeWatchedContent(b.db, response, c.GetString("userLanguage"))
+		c.JSON(http.StatusOK, response)
+	})
+
+	watched.POST("/trash/:id/restore", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		response, err := restoreWatched(b.db, userId, uint(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Permanently delete a removed watched item.
+	watched.DELETE("/trash/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		if err := deleteWatchedFromTrash(b.db, userId, uint(id)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Change status, rating, pinned or tags of (or delete) many watched items at once.
+	watched.POST("/bulk", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var br WatchedBulkRequest
+		if err := c.ShouldBindJSON(&br); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := bulkUpdateWatched(b.db, userId, br)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	watched.DELETE(":id", func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err == nil {
@@ -515,6 +618,58 @@ func (b *BaseRouter) addWatchedRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
+	// Start a new view (rewatch) of a watched item.
+	watched.POST("/view", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var ar WatchedViewStartRequest
+		if err := c.ShouldBindJSON(&ar); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := startWatchedView(b.db, userId, ar)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Update a view (eg, to finish it or change its dates).
+	watched.PUT("/view/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		var ar WatchedViewUpdateRequest
+		if err := c.ShouldBindJSON(&ar); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := updateWatchedView(b.db, userId, uint(id), ar)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	watched.DELETE("/view/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		response, err := deleteWatchedView(b.db, userId, uint(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	watched.POST("/season", func(c *gin.Context) {
 		userId := c.MustGet("userId").(uint)
 		var ar WatchedSeasonAddRequest
@@ -577,6 +732,62 @@ func (b *BaseRouter) addWatchedRoutes() {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Add a play (rewatch) to a watched episode.
+	watched.POST("/episode/:id/play", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		var ar WatchedEpisodePlayRequest
+		if err := c.ShouldBindJSON(&ar); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := addWatchedEpisodePlay(b.db, userId, uint(id), ar)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Change when an episode play was watched.
+	watched.PUT("/episode/play/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		var ar WatchedEpisodePlayRequest
+		if err := c.ShouldBindJSON(&ar); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := updateWatchedEpisodePlay(b.db, userId, uint(id), ar)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	watched.DELETE("/episode/play/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		if err := deleteWatchedEpisodePlay(b.db, userId, uint(id)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
 	watched.POST(":id/tag/:tagId", func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -837,6 +1048,102 @@ func (b *BaseRouter) addProfileRoutes() {
 		}
 		c.JSON(http.StatusOK, response)
 	})
+
+	// Get finished content counts by genre.
+	// Supports `type` query parameter (movie or tv) to only count one type of content.
+	profile.GET("/stats/genres", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		contentType := ContentType(c.Query("type"))
+		if contentType != "" && contentType != MOVIE && contentType != SHOW {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query parameter 'type' must be movie or tv"})
+			return
+		}
+		response, err := getWatchedGenreStats(b.db, userId, contentType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get people that appear most in finished content.
+	// Supports `job` query parameter to get crew with that job (eg: Director), cast is returned otherwise.
+	profile.GET("/stats/people", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		creditType := CREDIT_CAST
+		job := c.Query("job")
+		if job != "" {
+			creditType = CREDIT_CREW
+		}
+		response, err := getWatchedPersonStats(b.db, userId, creditType, job, 50)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get year in review stats.
+	// Supports `year` or `from` and `to` (YYYY-MM-DD) query parameters, defaults to this year.
+	profile.GET("/stats/review", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var rq StatsPeriodQuery
+		if err := c.ShouldBindQuery(&rq); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := getStatsReview(b.db, userId, rq)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get watches per day.
+	// Supports `year` or `from` and `to` (YYYY-MM-DD) query parameters, defaults to the last year.
+	profile.GET("/stats/heatmap", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var rq StatsPeriodQuery
+		if err := c.ShouldBindQuery(&rq); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := getStatsHeatmap(b.db, userId, rq)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get current and longest watching streaks.
+	profile.GET("/stats/streaks", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getStatsStreaks(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get rating distribution.
+	// Supports `type` query parameter (movie, tv or game) to only count one type.
+	profile.GET("/stats/ratings", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		t := c.Query("type")
+		if t != "" && t != "movie" && t != "tv" && t != "game" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query parameter 'type' must be movie, tv or game"})
+			return
+		}
+		response, err := getRatingHistogram(b.db, userId, t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
 }
 
 func (b *BaseRouter) addJellyfinRoutes() {
@@ -903,6 +1210,38 @@ func (b *BaseRouter) addUserRoutes() {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Get current users calendar feed token (created if they don't have one)
+	u.GET("/calendar", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getCalendarToken(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Regenerate current users calendar feed token
+	u.POST("/calendar", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := regenerateCalendarToken(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Disable current users calendar feed
+	u.DELETE("/calendar", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		if err := deleteCalendarToken(b.db, userId); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
 	// Update current user settings
 	u.POST("/update", func(c *gin.Context) {
 		userId := c.MustGet("userId").(uint)
@@ -1034,6 +1373,23 @@ func (b *BaseRouter) addFollowRoutes() {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Compare ratings with a followed user
+	f.GET("/compare/:userId", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		followedId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+		if err != nil {
+			slog.Error("failed to convert userId param to uint", "userId", followedId)
+			c.Status(400)
+			return
+		}
+		response, err := getTasteComparison(b.db, userId, uint(followedId))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	// Get follows thoughts on content
 	f.GET("/thoughts/:type/:tmdbId", func(c *gin.Context) {
 		t := c.Param("type")
@@ -1112,17 +1468,43 @@ func (b *BaseRouter) addServerRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
-	// Update plex host config
-	server.POST("/config/plex_host", func(c *gin.Context) {
-		var ur ValueRequest
-		err := c.ShouldBindJSON(&ur)
-		if err == nil {
-			resp, err := updateConfigPlexHost(ur.Value.(string))
-			if err != nil {
-				c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
-				return
-			}
-			c.JSON(http.StatusOK, resp)
+	// Get metrics for our outbound http requests (tmdb, arr servers, etc).
+	server.GET("/http_metrics", func(c *gin.Context) {
+		c.JSON(http.StatusOK, httpc.AllMetrics())
+	})
+
+	// Get size of the TMDB response cache.
+	server.GET("/tmdb_cache", func(c *gin.Context) {
+		response, err := getTMDBCacheStats(b.db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Purge the TMDB response cache.
+	// Optionally only for `endpoint` query param and endpoints under it (eg: `/tv/1399`).
+	server.DELETE("/tmdb_cache", func(c *gin.Context) {
+		_, err := purgeTMDBCache(b.db, c.Query("endpoint"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Update plex host config
+	server.POST("/config/plex_host", func(c *gin.Context) {
+		var ur ValueRequest
+		err := c.ShouldBindJSON(&ur)
+		if err == nil {
+			resp, err := updateConfigPlexHost(ur.Value.(string))
+			if err != nil {
+				c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, resp)
 			return
 		}
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
@@ -1277,6 +1659,24 @@ func (b *BaseRouter) addSonarrRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
+	// Request more seasons of a series that has already been added.
+	s.POST("/request/seasons", PermRequired(PERM_REQUEST_CONTENT), func(c *gin.Context) {
+		var ur arr.SonarrSeasonsRequest
+		err := c.ShouldBindJSON(&ur)
+		if err == nil {
+			userId := c.MustGet("userId").(uint)
+			perms := c.GetInt("userPermissions")
+			response, err := createSonarrSeasonsRequest(b.db, userId, perms, ur)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	})
+
 	s.GET("/request/:tmdbId", PermRequired(PERM_REQUEST_CONTENT), func(c *gin.Context) {
 		tmdbId, err := strconv.Atoi(c.Param("tmdbId"))
 		if err != nil {
@@ -1302,7 +1702,7 @@ func (b *BaseRouter) addSonarrRoutes() {
 				c.Status(400)
 				return
 			}
-			response, err := approveSonarrRequest(b.db, uint(requestId), ur)
+			response, err := approveSonarrRequest(b.db, uint(requestId), c.MustGet("userId").(uint), ur)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 				return
@@ -1313,6 +1713,26 @@ func (b *BaseRouter) addSonarrRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
+	// Get webhook path for server
+	s.GET("/webhook/:name", AdminRequired(), func(c *gin.Context) {
+		response, err := getSonarrWebhook(c.Param("name"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Generate a new webhook secret for server
+	s.POST("/webhook/:name", AdminRequired(), func(c *gin.Context) {
+		response, err := regenerateSonarrWebhook(c.Param("name"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	s.GET("/status/:serverName/:arrId", PermRequired(PERM_REQUEST_CONTENT), func(c *gin.Context) {
 		response, err := getSonarrQueueDetails(c.Param("serverName"), c.Param("arrId"))
 		if err != nil {
@@ -1468,7 +1888,7 @@ func (b *BaseRouter) addRadarrRoutes() {
 				c.Status(400)
 				return
 			}
-			response, err := approveRadarrRequest(b.db, uint(requestId), ur)
+			response, err := approveRadarrRequest(b.db, uint(requestId), c.MustGet("userId").(uint), ur)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 				return
@@ -1479,6 +1899,26 @@ func (b *BaseRouter) addRadarrRoutes() {
 		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	})
 
+	// Get webhook path for server
+	s.GET("/webhook/:name", AdminRequired(), func(c *gin.Context) {
+		response, err := getRadarrWebhook(c.Param("name"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Generate a new webhook secret for server
+	s.POST("/webhook/:name", AdminRequired(), func(c *gin.Context) {
+		response, err := regenerateRadarrWebhook(c.Param("name"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	s.GET("/status/:serverName/:arrId", PermRequired(PERM_REQUEST_CONTENT), func(c *gin.Context) {
 		response, err := getRadarrQueueDetails(c.Param("serverName"), c.Param("arrId"))
 		if err != nil {
@@ -1525,7 +1965,60 @@ func (b *BaseRouter) addArrRequestRoutes() {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Get our request quota and how much of it we have left.
+	s.GET("/quota", PermRequired(PERM_REQUEST_CONTENT), func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getArrRequestQuota(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Get comments and status history of a request.
+	// Only for the user that made the request, or admins.
+	s.GET("/:id/history", func(c *gin.Context) {
+		requestId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			slog.Error("Couldn't parse request id", "request_id", requestId)
+			c.Status(400)
+			return
+		}
+		userId := c.MustGet("userId").(uint)
+		response, err := getArrRequestHistory(b.db, uint(requestId), userId, c.GetInt("userPermissions"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Comment on a request.
+	// Only for the user that made the request, or admins.
+	s.POST("/:id/comment", func(c *gin.Context) {
+		requestId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			slog.Error("Couldn't parse request id", "request_id", requestId)
+			c.Status(400)
+			return
+		}
+		var cr ArrRequestCommentAddRequest
+		if err := c.ShouldBindJSON(&cr); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		userId := c.MustGet("userId").(uint)
+		response, err := addArrRequestComment(b.db, uint(requestId), userId, c.GetInt("userPermissions"), cr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	// Deny a request (for manage_requests view), only for admins.
+	// Body (with a reason) is optional, unless server requires a reason.
 	s.POST("/deny/:id", AdminRequired(), func(c *gin.Context) {
 		requestId, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -1533,7 +2026,12 @@ func (b *BaseRouter) addArrRequestRoutes() {
 			c.Status(400)
 			return
 		}
-		err = denyArrRequest(b.db, uint(requestId))
+		var dr ArrRequestDenyRequest
+		if err := c.ShouldBindJSON(&dr); err != nil && !errors.Is(err, io.EOF) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		err = denyArrRequest(b.db, uint(requestId), c.MustGet("userId").(uint), dr)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 			return
@@ -1542,6 +2040,67 @@ func (b *BaseRouter) addArrRequestRoutes() {
 	})
 }
 
+// Webhooks sent to us from sonarr/radarr "Connect" settings.
+// Not authenticated, instead each server has its own secret in the url.
+func (b *BaseRouter) addArrWebhookRoutes() {
+	wh := b.rg.Group("/arr/webhook")
+
+	wh.POST("/son/:name/:secret", func(c *gin.Context) {
+		var p arr.WebhookPayload
+		err := c.ShouldBindJSON(&p)
+		if err == nil {
+			err := processArrWebhook(b.db, arr.SONARR, c.Param("name"), c.Param("secret"), p)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.Status(http.StatusOK)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	})
+
+	wh.POST("/rad/:name/:secret", func(c *gin.Context) {
+		var p arr.WebhookPayload
+		err := c.ShouldBindJSON(&p)
+		if err == nil {
+			err := processArrWebhook(b.db, arr.RADARR, c.Param("name"), c.Param("secret"), p)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.Status(http.StatusOK)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	})
+}
+
+// Rules for routing requests to our sonarr/radarr servers, only for admins.
+func (b *BaseRouter) addArrRoutingRoutes() {
+	r := b.rg.Group("/arr/routing").Use(AuthRequired(b.db), AdminRequired())
+
+	r.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, getArrRoutingRules())
+	})
+
+	// Replace all rules, in the order they should be checked.
+	r.PUT("", func(c *gin.Context) {
+		var rules []ArrRoutingRule
+		err := c.ShouldBindJSON(&rules)
+		if err == nil {
+			err := setArrRoutingRules(rules)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.Status(http.StatusOK)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	})
+}
+
 func (b *BaseRouter) addJobRoutes() {
 	job := b.rg.Group("/job").Use(AuthRequired(nil))
 
@@ -1674,3 +2233,307 @@ func (b *BaseRouter) addTagRoutes() {
 		c.Status(http.StatusOK)
 	})
 }
+
+func (b *BaseRouter) addCalendarRoutes() {
+	// Not behind auth so calendar apps can subscribe,
+	// the token identifies the user instead.
+	cal := b.rg.Group("/calendar")
+
+	cal.GET("/:token", func(c *gin.Context) {
+		token := strings.TrimSuffix(c.Param("token"), ".ics")
+		response, err := getCalendarFeed(b.db, token)
+		if err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(response))
+	})
+}
+
+func (b *BaseRouter) addNotificationRoutes() {
+	notification := b.rg.Group("/notification").Use(AuthRequired(nil))
+
+	// Get our notifications.
+	// Supports `unread` query parameter to only get unread notifications.
+	notification.GET("", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getNotifications(b.db, userId, c.Query("unread") == "1")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Mark all notifications as read.
+	notification.POST("/read", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		err := readAllNotifications(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Mark a notification as read.
+	notification.POST("/read/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			slog.Error("notification read route: failed to process notification id.", "error", err.Error(), "id", c.Param("id"))
+			return
+		}
+		err = readNotification(b.db, userId, uint(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	notification.DELETE(":id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			slog.Error("notification delete route: failed to process notification id.", "error", err.Error(), "id", c.Param("id"))
+			return
+		}
+		err = deleteNotification(b.db, userId, uint(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Get our outbound notification channels.
+	notification.GET("/channel", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		response, err := getNotificationChannels(b.db, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Add an outbound notification channel.
+	notification.POST("/channel", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var cr NotificationChannelRequest
+		if err := c.ShouldBindJSON(&cr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err := validateNotificationChannelURL(cr.URL); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := addNotificationChannel(b.db, userId, cr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Update an outbound notification channel.
+	notification.PUT("/channel/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			slog.Error("notification channel update route: failed to process channel id.", "error", err.Error(), "id", c.Param("id"))
+			return
+		}
+		var cr NotificationChannelRequest
+		if err := c.ShouldBindJSON(&cr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err := validateNotificationChannelURL(cr.URL); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := updateNotificationChannel(b.db, userId, uint(id), cr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Delete an outbound notification channel.
+	notification.DELETE("/channel/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			slog.Error("notification channel delete route: failed to process channel id.", "error", err.Error(), "id", c.Param("id"))
+			return
+		}
+		if err := deleteNotificationChannel(b.db, userId, uint(id)); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Send a test notification to an outbound notification channel.
+	notification.POST("/channel/:id/test", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			slog.Error("notification channel test route: failed to process channel id.", "error", err.Error(), "id", c.Param("id"))
+			return
+		}
+		if err := testNotificationChannel(b.db, userId, uint(id)); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+}
+
+func (b *BaseRouter) addGoalRoutes() {
+	goal := b.rg.Group("/goal").Use(AuthRequired(nil))
+
+	// Get users goals and instance challenges, with progress.
+	// Supports `year` query parameter to only get goals for that year.
+	goal.GET("", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		year := 0
+		if yearQ := c.Query("year"); yearQ != "" {
+			y, err := strconv.Atoi(yearQ)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query parameter 'year' must be a number"})
+				return
+			}
+			year = y
+		}
+		response, err := getGoals(b.db, userId, year)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	goal.POST("", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		var gr GoalRequest
+		if err := c.ShouldBindJSON(&gr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := addGoal(b.db, &userId, gr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	goal.PUT("/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		var gr GoalRequest
+		if err := c.ShouldBindJSON(&gr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := updateGoal(b.db, &userId, uint(id), gr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	goal.DELETE("/:id", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		if err := deleteGoal(b.db, &userId, uint(id)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// Get challenge progress of user and the users they follow.
+	goal.GET("/:id/leaderboard", func(c *gin.Context) {
+		userId := c.MustGet("userId").(uint)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		response, err := getChallengeLeaderboard(b.db, userId, uint(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Manage instance wide challenges.
+	challenge := b.rg.Group("/goal/challenge").Use(AuthRequired(b.db), AdminRequired())
+
+	challenge.POST("", func(c *gin.Context) {
+		var gr GoalRequest
+		if err := c.ShouldBindJSON(&gr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := addGoal(b.db, nil, gr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	challenge.PUT("/:id", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		var gr GoalRequest
+		if err := c.ShouldBindJSON(&gr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		response, err := updateGoal(b.db, nil, uint(id), gr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	challenge.DELETE("/:id", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Status(400)
+			return
+		}
+		if err := deleteGoal(b.db, nil, uint(id)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+}
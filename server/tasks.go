@@ -64,6 +64,48 @@ func setupTasks(db *gorm.DB) {
 			},
 			dd: 60 * time.Second,
 		},
+		"Check Arr Requests Available": {
+			f: func() {
+				checkArrRequestsAvailable(db)
+			},
+			dd: 5 * time.Minute,
+		},
+		"Index Arr Libraries": {
+			f: func() {
+				indexArrLibraries(db)
+			},
+			dd: 1 * time.Hour,
+		},
+		"Check Goal Milestones": {
+			f: func() {
+				checkGoalMilestones(db)
+			},
+			dd: 1 * time.Hour,
+		},
+		"Purge Watched Trash": {
+			f: func() {
+				purgeWatchedTrash(db)
+			},
+			dd: 24 * time.Hour,
+		},
+		"Evict TMDB Cache": {
+			f: func() {
+				evictTMDBCache(db)
+			},
+			dd: 1 * time.Hour,
+		},
+		"Refresh Stale Content": {
+			f: func() {
+				refreshStaleContent(db)
+			},
+			dd: 15 * time.Minute,
+		},
+		"Check New Releases": {
+			f: func() {
+				checkNewReleases(db)
+			},
+			dd: 6 * time.Hour,
+		},
 		"Cleanup Images": {
 			f: func() {
 				cleanupImages(db)
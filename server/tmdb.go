@@ -5,11 +5,15 @@ import (
 	"errors"
 	"io"
 	"log/slog"
-	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/sbondCo/Watcharr/httpc"
 )
 
+// TMDB allows roughly 50 requests per second, stay under it.
+var tmdbHttp = httpc.New("tmdb", httpc.Options{Timeout: 15 * time.Second, MaxRetries: 3, RateLimit: 40})
+
 type TMDBSearchMultiResponse struct {
 	Page         int                      `json:"page"`
 	Results      []TMDBSearchMultiResults `json:"results"`
@@ -121,17 +125,14 @@ type TMDBSearchPeopleResponse struct {
 }
 
 type TMDBContentDetails struct {
-	ID           int    `json:"id"`
-	BackdropPath string `json:"backdrop_path"`
-	Genres       []struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
-	} `json:"genres"`
-	PosterPath          string  `json:"poster_path"`
-	Homepage            string  `json:"homepage"`
-	Popularity          float32 `json:"popularity"`
-	Overview            string  `json:"overview"`
-	OriginalLanguage    string  `json:"original_language"`
+	ID                  int             `json:"id"`
+	BackdropPath        string          `json:"backdrop_path"`
+	Genres              []TMDBNamedItem `json:"genres"`
+	PosterPath          string          `json:"poster_path"`
+	Homepage            string          `json:"homepage"`
+	Popularity          float32         `json:"popularity"`
+	Overview            string          `json:"overview"`
+	OriginalLanguage    string          `json:"original_language"`
 	ProductionCompanies []struct {
 		ID            int    `json:"id"`
 		LogoPath      string `json:"logo_path"`
@@ -167,10 +168,13 @@ type TMDBMovieDetails struct {
 	Video               bool   `json:"video"`
 
 	// Extra items because we use `append_to_response` on the request
-	Videos         TMDBContentVideos    `json:"videos"`
-	WatchProviders interface{}          `json:"watch/providers"`
-	Similar        TMDBMovieSimilar     `json:"similar"`
-	ExternalIds    TMDBExternalIdsMovie `json:"external_ids"`
+	Videos         TMDBContentVideos      `json:"videos"`
+	WatchProviders interface{}            `json:"watch/providers"`
+	Similar        TMDBMovieSimilar       `json:"similar"`
+	ExternalIds    TMDBExternalIdsMovie   `json:"external_ids"`
+	Keywords       *TMDBKeywords          `json:"keywords,omitempty"`
+	Credits        *TMDBContentCredits    `json:"credits,omitempty"`
+	ReleaseDates   *TMDBMovieReleaseDates `json:"release_dates,omitempty"`
 }
 
 type TMDBShowDetails struct {
@@ -182,25 +186,14 @@ type TMDBShowDetails struct {
 		Gender      int    `json:"gender"`
 		ProfilePath string `json:"profile_path"`
 	} `json:"created_by"`
-	EpisodeRunTime   []int    `json:"episode_run_time"`
-	FirstAirDate     string   `json:"first_air_date"`
-	InProduction     bool     `json:"in_production"`
-	Languages        []string `json:"languages"`
-	LastAirDate      string   `json:"last_air_date"`
-	LastEpisodeToAir struct {
-		AirDate        string  `json:"air_date"`
-		EpisodeNumber  int     `json:"episode_number"`
-		ID             int     `json:"id"`
-		Name           string  `json:"name"`
-		Overview       string  `json:"overview"`
-		ProductionCode string  `json:"production_code"`
-		SeasonNumber   int     `json:"season_number"`
-		StillPath      string  `json:"still_path"`
-		VoteAverage    float64 `json:"vote_average"`
-		VoteCount      uint32  `json:"vote_count"`
-	} `json:"last_episode_to_air"`
-	Name             string `json:"name"`
-	NextEpisodeToAir any    `json:"next_episode_to_air"`
+	EpisodeRunTime   []int             `json:"episode_run_time"`
+	FirstAirDate     string            `json:"first_air_date"`
+	InProduction     bool              `json:"in_production"`
+	Languages        []string          `json:"languages"`
+	LastAirDate      string            `json:"last_air_date"`
+	LastEpisodeToAir TMDBEpisodeToAir  `json:"last_episode_to_air"`
+	Name             string            `json:"name"`
+	NextEpisodeToAir *TMDBEpisodeToAir `json:"next_episode_to_air"`
 	Networks         []struct {
 		Name          string `json:"name"`
 		ID            int    `json:"id"`
@@ -227,7 +220,37 @@ type TMDBShowDetails struct {
 	WatchProviders interface{}         `json:"watch/providers"`
 	Similar        TMDBShowSimilar     `json:"similar"`
 	ExternalIds    TMDBExternalIdsShow `json:"external_ids"`
-	Keywords       TMDBKeywords        `json:"keywords"`
+	Keywords       *TMDBKeywords       `json:"keywords,omitempty"`
+	Credits        *TMDBContentCredits `json:"credits,omitempty"`
+}
+
+type TMDBEpisodeToAir struct {
+	AirDate        string  `json:"air_date"`
+	EpisodeNumber  int     `json:"episode_number"`
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Overview       string  `json:"overview"`
+	ProductionCode string  `json:"production_code"`
+	Runtime        int     `json:"runtime"`
+	SeasonNumber   int     `json:"season_number"`
+	StillPath      string  `json:"still_path"`
+	VoteAverage    float64 `json:"vote_average"`
+	VoteCount      uint32  `json:"vote_count"`
+}
+
+type TMDBMovieReleaseDates struct {
+	ID      int `json:"id"`
+	Results []struct {
+		Iso31661     string `json:"iso_3166_1"`
+		ReleaseDates []struct {
+			Certification string    `json:"certification"`
+			Note          string    `json:"note"`
+			ReleaseDate   time.Time `json:"release_date"`
+			// 1 Premiere, 2 Theatrical (limited), 3 Theatrical,
+			// 4 Digital, 5 Physical, 6 TV
+			Type int `json:"type"`
+		} `json:"release_dates"`
+	} `json:"results"`
 }
 
 type WatchProvider struct {
@@ -563,12 +586,18 @@ type TMDBExternalIdsShow struct {
 	TvrageID    int    `json:"tvrage_id"`
 }
 
+// Genres or keywords returned from TMDB.
+type TMDBNamedItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 type TMDBKeywords struct {
 	// ID      int `json:"id"`
-	Results []struct {
-		Name string `json:"name"`
-		ID   int    `json:"id"`
-	} `json:"results"`
+	// Movies
+	Keywords []TMDBNamedItem `json:"keywords,omitempty"`
+	// Shows
+	Results []TMDBNamedItem `json:"results,omitempty"`
 }
 
 type TMDBRegions struct {
@@ -587,6 +616,12 @@ func getTMDBKey() string {
 }
 
 func tmdbAPIRequest(ep string, p map[string]string) ([]byte, error) {
+	return tmdbAPIRequestWithCache(ep, p, true)
+}
+
+// Make request to TMDB, going through our cache.
+// allowStale - If a stale response can be returned (while it's refreshed in the background).
+func tmdbAPIRequestWithCache(ep string, p map[string]string, allowStale bool) ([]byte, error) {
 	slog.Debug("tmdbAPIRequest", "endpoint", ep, "params", p)
 	base, err := url.Parse("https://api.themoviedb.org/3")
 	if err != nil {
@@ -598,30 +633,37 @@ func tmdbAPIRequest(ep string, p map[string]string) ([]byte, error) {
 
 	// Query params
 	params := url.Values{}
-	params.Add("api_key", getTMDBKey())
-	params.Add("language", "en-US")
 	for k, v := range p {
 		params.Add(k, v)
 	}
-
-	// Add params to url
-	base.RawQuery = params.Encode()
-
-	// Run get request
-	res, err := http.Get(base.String())
-	if err != nil {
-		return nil, err
-	}
-	body, err := io.ReadAll(res.Body)
-	res.Body.Close()
-	if err != nil {
-		return nil, err
-	}
-	if res.StatusCode != 200 {
-		slog.Error("TMDB non 200 status code:", "status_code", res.StatusCode)
-		return nil, errors.New(string(body))
+	if params.Get("language") == "" {
+		params.Set("language", tmdbDefaultLanguage)
 	}
-	return body, nil
+
+	// Responses are cached by endpoint and params (encoded params are sorted by key).
+	key := ep + "?" + params.Encode()
+
+	return tmdbCachedRequest(ep, key, allowStale, func() ([]byte, error) {
+		// Add params to url
+		params.Set("api_key", getTMDBKey())
+		base.RawQuery = params.Encode()
+
+		// Run get request
+		res, err := tmdbHttp.Get(base.String())
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != 200 {
+			slog.Error("TMDB non 200 status code:", "status_code", res.StatusCode)
+			return nil, errors.New(string(body))
+		}
+		return body, nil
+	})
 }
 
 func tmdbRequest(ep string, p map[string]string, resp interface{}) error {
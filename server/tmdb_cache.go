@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cached TMDB response, so we aren't cold after a restart
+// and can keep serving content through TMDB outages.
+type TMDBCacheEntry struct {
+	// Endpoint and params of the request (without api key).
+	CacheKey string `gorm:"primaryKey"`
+	Endpoint string `gorm:"index"`
+	Body     []byte
+	// Size of Body in bytes.
+	Size int
+	// When response was fetched from TMDB.
+	FetchedAt time.Time `gorm:"index"`
+	// When response was last served, for evicting least recently used.
+	LastUsedAt time.Time `gorm:"index"`
+}
+
+type TMDBCacheStats struct {
+	Entries int64 `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	// Max size of the cache in bytes.
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+type tmdbCacheTTL struct {
+	re  *regexp.Regexp
+	ttl time.Duration
+}
+
+// How long responses are fresh for, first matching endpoint wins.
+var tmdbCacheTTLs = []tmdbCacheTTL{
+	{regexp.MustCompile(`^/search/`), 1 * time.Hour},
+	{regexp.MustCompile(`^/trending/`), 3 * time.Hour},
+	{regexp.MustCompile(`^/discover/`), 6 * time.Hour},
+	{regexp.MustCompile(`^/(movie|tv)/(upcoming|on_the_air)`), 6 * time.Hour},
+	{regexp.MustCompile(`^/tv/\d+/season/`), 12 * time.Hour},
+	{regexp.MustCompile(`^/(movie|tv)/\d+`), 24 * time.Hour},
+	{regexp.MustCompile(`^/person/`), 7 * 24 * time.Hour},
+	{regexp.MustCompile(`^/(watch/providers|configuration)/`), 7 * 24 * time.Hour},
+}
+
+const (
+	tmdbCacheDefaultTTL = 24 * time.Hour
+	// How long past its ttl a response can still be served
+	// while we refresh it in the background.
+	tmdbCacheStaleFor = 7 * 24 * time.Hour
+	// Default max size of cache when not set in config.
+	tmdbCacheDefaultMaxMB = 200
+	// Only update LastUsedAt when it's older than this, so
+	// every cache hit doesn't result in a write.
+	tmdbCacheTouchEvery = time.Hour
+)
+
+var (
+	tmdbCacheDb *gorm.DB
+	// Keys being revalidated in the background.
+	tmdbCacheRevalidating   = map[string]bool{}
+	tmdbCacheRevalidatingMu sync.Mutex
+)
+
+func setupTMDBCache(db *gorm.DB) {
+	tmdbCacheDb = db
+}
+
+func getTMDBCacheTTL(ep string) time.Duration {
+	for _, t := range tmdbCacheTTLs {
+		if t.re.MatchString(ep) {
+			return t.ttl
+		}
+	}
+	return tmdbCacheDefaultTTL
+}
+
+func getTMDBCacheMaxBytes() int64 {
+	mb := Config.TMDB_CACHE_MAX_MB
+	if mb <= 0 {
+		mb = tmdbCacheDefaultMaxMB
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// Get response for request from cache if possible, otherwise `fetch` it.
+// Stale responses are served while being refreshed in the background,
+// and any cached response is served if fetching fails.
+func tmdbCachedRequest(ep string, key string, allowStale bool, fetch func() ([]byte, error)) ([]byte, error) {
+	if tmdbCacheDb == nil {
+		return fetch()
+	}
+	var entry TMDBCacheEntry
+	res := tmdbCacheDb.Where("cache_key = ?", key).Limit(1).Find(&entry)
+	if res.Error != nil {
+		slog.Error("tmdbCachedRequest: Failed to get entry from cache", "key", key, "error", res.Error)
+	}
+	if entry.CacheKey != "" {
+		age := time.Since(entry.FetchedAt)
+		ttl := getTMDBCacheTTL(ep)
+		if age < ttl {
+			touchTMDBCacheEntry(entry)
+			return entry.Body, nil
+		}
+		if allowStale && age < ttl+tmdbCacheStaleFor {
+			slog.Debug("tmdbCachedRequest: Serving stale response and revalidating", "key", key, "age", age)
+			touchTMDBCacheEntry(entry)
+			go revalidateTMDBCacheEntry(ep, key, fetch)
+			return entry.Body, nil
+		}
+	}
+	body, err := fetch()
+	if err != nil {
+		if entry.CacheKey != "" {
+			slog.Warn("tmdbCachedRequest: Request failed, serving expired response from cache", "key", key, "error", err)
+			return entry.Body, nil
+		}
+		return nil, err
+	}
+	saveTMDBCacheEntry(ep, key, body)
+	return body, nil
+}
+
+func revalidateTMDBCacheEntry(ep string, key string, fetch func() ([]byte, error)) {
+	tmdbCacheRevalidatingMu.Lock()
+	if tmdbCacheRevalidating[key] {
+		tmdbCacheRevalidatingMu.Unlock()
+		return
+	}
+	tmdbCacheRevalidating[key] = true
+	tmdbCacheRevalidatingMu.Unlock()
+	defer func() {
+		tmdbCacheRevalidatingMu.Lock()
+		delete(tmdbCacheRevalidating, key)
+		tmdbCacheRevalidatingMu.Unlock()
+	}()
+	body, err := fetch()
+	if err != nil {
+		slog.Error("revalidateTMDBCacheEntry: Failed to refresh response", "key", key, "error", err)
+		return
+	}
+	saveTMDBCacheEntry(ep, key, body)
+}
+
+func saveTMDBCacheEntry(ep string, key string, body []byte) {
+	now := time.Now()
+	res := tmdbCacheDb.Clauses(clause.OnConflict{UpdateAll: true}).Create(&TMDBCacheEntry{
+		CacheKey:   key,
+		Endpoint:   ep,
+		Body:       body,
+		Size:       len(body),
+		FetchedAt:  now,
+		LastUsedAt: now,
+	})
+	if res.Error != nil {
+		slog.Error("saveTMDBCacheEntry: Failed to save response to cache", "key", key, "error", res.Error)
+	}
+}
+
+func touchTMDBCacheEntry(entry TMDBCacheEntry) {
+	if time.Since(entry.LastUsedAt) < tmdbCacheTouchEvery {
+		return
+	}
+	res := tmdbCacheDb.Model(&TMDBCacheEntry{}).Where("cache_key = ?", entry.CacheKey).Update("last_used_at", time.Now())
+	if res.Error != nil {
+		slog.Error("touchTMDBCacheEntry: Failed to update entry", "key", entry.CacheKey, "error", res.Error)
+	}
+}
+
+func getTMDBCacheStats(db *gorm.DB) (TMDBCacheStats, error) {
+	var stats TMDBCacheStats
+	res := db.Model(&TMDBCacheEntry{}).Select("COUNT(*) AS entries, COALESCE(SUM(size), 0) AS bytes").Scan(&stats)
+	if res.Error != nil {
+		slog.Error("getTMDBCacheStats: Failed to get cache stats", "error", res.Error)
+		return TMDBCacheStats{}, errors.New("failed to get cache stats")
+	}
+	stats.MaxBytes = getTMDBCacheMaxBytes()
+	return stats, nil
+}
+
+// Remove least recently used entries until the cache is under its max size.
+func evictTMDBCache(db *gorm.DB) {
+	stats, err := getTMDBCacheStats(db)
+	if err != nil {
+		return
+	}
+	over := stats.Bytes - stats.MaxBytes
+	if over <= 0 {
+		return
+	}
+	slog.Info("evictTMDBCache: Cache is over max size, evicting least recently used entries", "bytes", stats.Bytes, "max_bytes", stats.MaxBytes)
+	for over > 0 {
+		var entries []TMDBCacheEntry
+		res := db.Select("cache_key", "size").Order("last_used_at ASC").Limit(100).Find(&entries)
+		if res.Error != nil {
+			slog.Error("evictTMDBCache: Failed to get entries", "error", res.Error)
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+		keys := []string{}
+		for _, e := range entries {
+			keys = append(keys, e.CacheKey)
+			over -= int64(e.Size)
+			if over <= 0 {
+				break
+			}
+		}
+		if res := db.Where("cache_key IN ?", keys).Delete(&TMDBCacheEntry{}); res.Error != nil {
+			slog.Error("evictTMDBCache: Failed to delete entries", "error", res.Error)
+			return
+		}
+	}
+}
+
+// Remove cached responses, all of them or only those for
+// `endpoint` and endpoints under it (eg: `/tv/1399/credits`).
+func purgeTMDBCache(db *gorm.DB, endpoint string) (int64, error) {
+	q := db.Where("1 = 1")
+	if endpoint != "" {
+		endpoint = strings.TrimSuffix(endpoint, "/")
+		q = db.Where(`endpoint = ? OR endpoint LIKE ? ESCAPE '\'`, endpoint, likeEscaper.Replace(endpoint)+"/%")
+	}
+	res := q.Delete(&TMDBCacheEntry{})
+	if res.Error != nil {
+		slog.Error("purgeTMDBCache: Failed to delete entries", "error", res.Error)
+		return 0, errors.New("failed to purge cache")
+	}
+	slog.Info("purgeTMDBCache: Purged cache", "endpoint", endpoint, "entries", res.RowsAffected)
+	return res.RowsAffected, nil
+}
@@ -64,6 +64,11 @@ func userUpdate(db *gorm.DB, userId uint, ur UserSettings) (UserSettings, error)
 	if ur.Country != nil {
 		user.Country = ur.Country
 	}
+	langChanged := false
+	if ur.Language != nil {
+		langChanged = user.Language == nil || *user.Language != *ur.Language
+		user.Language = ur.Language
+	}
 	if ur.RatingSystem != nil {
 		user.RatingSystem = ur.RatingSystem
 	}
@@ -71,6 +76,9 @@ func userUpdate(db *gorm.DB, userId uint, ur UserSettings) (UserSettings, error)
 		user.RatingStep = ur.RatingStep
 	}
 	db.Save(&user)
+	if langChanged && !isDefaultContentLanguage(*user.Language) {
+		go cacheWatchedContentTranslations(db, userId, *user.Language)
+	}
 	return UserSettings{
 		Private:                  user.Private,
 		PrivateThoughts:          user.PrivateThoughts,
@@ -78,6 +86,7 @@ func userUpdate(db *gorm.DB, userId uint, ur UserSettings) (UserSettings, error)
 		IncludePreviouslyWatched: user.IncludePreviouslyWatched,
 		AutomateShowStatuses:     user.AutomateShowStatuses,
 		Country:                  user.Country,
+		Language:                 user.Language,
 	}, nil
 }
 
@@ -96,6 +105,7 @@ func userGetSettings(db *gorm.DB, userId uint) (UserSettings, error) {
 		IncludePreviouslyWatched: user.IncludePreviouslyWatched,
 		AutomateShowStatuses:     user.AutomateShowStatuses,
 		Country:                  user.Country,
+		Language:                 user.Language,
 		RatingSystem:             user.RatingSystem,
 		RatingStep:               user.RatingStep,
 	}, nil
@@ -16,10 +16,18 @@ type ManagedUser struct {
 	Type        UserType  `json:"type"`
 	Permissions int       `json:"permissions"`
 	Private     bool      `json:"private"`
+	// Request quota overrides (nil when using server default, 0 when unlimited).
+	RequestQuotaMovies  *int `json:"requestQuotaMovies"`
+	RequestQuotaSeasons *int `json:"requestQuotaSeasons"`
 }
 
 type UpdateUserRequest struct {
 	Permissions *int `json:"permissions"`
+	// Override the servers request quota for this user, 0 allows unlimited requests.
+	RequestQuotaMovies  *int `json:"requestQuotaMovies" binding:"omitempty,min=0"`
+	RequestQuotaSeasons *int `json:"requestQuotaSeasons" binding:"omitempty,min=0"`
+	// Remove the request quota overrides, so the server defaults are used.
+	ResetRequestQuota bool `json:"resetRequestQuota"`
 }
 
 func getAllUsers(db *gorm.DB) ([]ManagedUser, error) {
@@ -34,7 +42,7 @@ func getAllUsers(db *gorm.DB) ([]ManagedUser, error) {
 // Update a user. For management views, for admin to update another user.
 func manageUser(db *gorm.DB, userId uint, ur UpdateUserRequest) error {
 	// Error now if no userId or any UpdateUserRequest property was provided.
-	if userId == 0 || (ur.Permissions == nil) {
+	if userId == 0 || (ur.Permissions == nil && ur.RequestQuotaMovies == nil && ur.RequestQuotaSeasons == nil && !ur.ResetRequestQuota) {
 		slog.Error("manageUser: invalid arguments", "user_id", userId)
 		return errors.New("invalid arguments, ensure a valid userId and at least one property has been provided for updating")
 	}
@@ -49,6 +57,17 @@ func manageUser(db *gorm.DB, userId uint, ur UpdateUserRequest) error {
 			toUpdate["permissions"] = *ur.Permissions
 		}
 	}
+	if ur.ResetRequestQuota {
+		toUpdate["request_quota_movies"] = nil
+		toUpdate["request_quota_seasons"] = nil
+	} else {
+		if ur.RequestQuotaMovies != nil {
+			toUpdate["request_quota_movies"] = *ur.RequestQuotaMovies
+		}
+		if ur.RequestQuotaSeasons != nil {
+			toUpdate["request_quota_seasons"] = *ur.RequestQuotaSeasons
+		}
+	}
 	if res := db.Model(&User{}).Where("id = ?", userId).Updates(toUpdate); res.Error != nil {
 		slog.Error("manageUser: failed to update user in database", "user_id", userId, "error", res.Error)
 		return errors.New("failed to update user in database")
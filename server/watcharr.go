@@ -69,13 +69,29 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	hadReleaseDates := db.Migrator().HasTable(&ContentReleaseDate{})
+	hadEpisodePlays := db.Migrator().HasTable(&WatchedEpisodePlay{})
 	err = db.AutoMigrate(
 		&User{},
 		&UserServices{},
 		&Content{},
+		&ContentTranslation{},
+		&Genre{},
+		&Keyword{},
+		&Person{},
+		&ContentGenre{},
+		&ContentKeyword{},
+		&ContentCredit{},
+		&ContentReleaseDate{},
+		&Season{},
+		&Episode{},
+		&NotificationChannel{},
+		&WatchedView{},
+		&Goal{},
 		&Watched{},
 		&WatchedSeason{},
 		&WatchedEpisode{},
+		&WatchedEpisodePlay{},
 		&Activity{},
 		&Token{},
 		&Follow{},
@@ -83,10 +99,40 @@ func main() {
 		&Game{},
 		&ArrRequest{},
 		&Tag{},
+		&Notification{},
+		&ArrRequestEvent{},
+		&ArrRequestComment{},
+		&ArrLibraryItem{},
+		&TMDBCacheEntry{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto migrate database:", err)
 	}
+	// Requests used to be unique per content and server only,
+	// remove the old index so seasons can be requested for existing content.
+	if db.Migrator().HasIndex(&ArrRequest{}, "sn_to_cid") {
+		if err := db.Migrator().DropIndex(&ArrRequest{}, "sn_to_cid"); err != nil {
+			log.Fatal("Failed to drop old arr request index:", err)
+		}
+	}
+
+	// Episodes finished before plays were recorded need one to keep their history.
+	if !hadEpisodePlays {
+		if err := backfillEpisodePlays(db); err != nil {
+			log.Fatal("Failed to add plays for existing watched episodes:", err)
+		}
+	}
+	// Release dates are only stored when movies are cached, so get the
+	// content refresh task to fetch them for any upcoming or recent movies.
+	if !hadReleaseDates {
+		if res := db.Model(&Content{}).
+			Where("type = ? AND (release_date IS NULL OR release_date >= ?)", MOVIE, time.Now().AddDate(-1, 0, 0)).
+			UpdateColumn("updated_at", nil); res.Error != nil {
+			log.Fatal("Failed to mark movies for release date refresh:", res.Error)
+		}
+	}
+
+	setupTMDBCache(db)
 
 	if isProd {
 		go runUI()
@@ -156,9 +202,14 @@ func main() {
 	br.addSonarrRoutes()
 	br.addRadarrRoutes()
 	br.addArrRequestRoutes()
+	br.addArrWebhookRoutes()
+	br.addArrRoutingRoutes()
 	br.addJobRoutes()
 	br.addTaskRoutes()
 	br.addTagRoutes()
+	br.addNotificationRoutes()
+	br.addCalendarRoutes()
+	br.addGoalRoutes()
 	br.rg.Static("/img", path.Join(DataPath, "img"))
 
 	go setupTasks(db)
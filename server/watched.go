@@ -43,6 +43,7 @@ type Watched struct {
 	WatchedSeasons  []WatchedSeason  `json:"watchedSeasons,omitempty"`  // For shows
 	WatchedEpisodes []WatchedEpisode `json:"watchedEpisodes,omitempty"` // For shows
 	Tags            []Tag            `json:"tags,omitempty" gorm:"many2many:watched_tags;"`
+	Views           []WatchedView    `json:"views,omitempty"`
 	// The last season that was viewed by the user for this watched entry.
 	// Only applies to tv shows of course.
 	LastViewedSeason *int `json:"lastViewedSeason,omitempty"`
@@ -75,22 +76,17 @@ type WatchedRemoveResponse struct {
 	NewActivity Activity `json:"newActivity"`
 }
 
-func getWatched(db *gorm.DB, userId uint) []Watched {
+func getWatched(db *gorm.DB, userId uint) ([]Watched, error) {
 	watched := new([]Watched)
-	res := db.Model(&Watched{}).
-		Preload("Content").
-		Preload("Game").
-		Preload("Game.Poster").
-		Preload("Activity").
-		Preload("WatchedSeasons").
-		Preload("WatchedEpisodes").
-		Preload("Tags").
+	res := preloadWatched(db.Model(&Watched{}), false).
 		Where("user_id = ?", userId).
 		Find(&watched)
 	if res.Error != nil {
-		panic(res.Error)
+		slog.Error("getWatched: Failed to get watched list", "user_id", userId, "error", res.Error)
+		return []Watched{}, errors.New("failed to get watched list")
 	}
-	return *watched
+	addWatchedEpisodeNames(db, *watched)
+	return *watched, nil
 }
 
 // Get a watched list item by id (must be for `userId`).
@@ -125,7 +121,8 @@ func getPublicWatched(db *gorm.DB, userId uint, username string) ([]Watched, err
 	watched := new([]Watched)
 	res = db.Model(&Watched{}).Preload("Content").Preload("Game").Preload("Game.Poster").Preload("Activity").Where("user_id = ?", userId).Find(&watched)
 	if res.Error != nil {
-		panic(res.Error)
+		slog.Error("getPublicWatched: Failed to get watched list", "user_id", userId, "error", res.Error)
+		return []Watched{}, errors.New("failed to get watched list")
 	}
 	return *watched, nil
 }
@@ -198,6 +195,13 @@ func addWatched(db *gorm.DB, userId uint, ar WatchedAddRequest, at ActivityType)
 	}
 	watched.Activity = append(watched.Activity, activity)
 	watched.Content = &content
+	if ar.Status == FINISHED {
+		finishedAt := time.Now()
+		if !ar.WatchedDate.IsZero() {
+			finishedAt = ar.WatchedDate
+		}
+		finishWatchedView(db, userId, watched.ID, nil, ar.Rating, finishedAt)
+	}
 	return watched, nil
 }
 
@@ -211,6 +215,7 @@ func updateWatched(db *gorm.DB, userId uint, id uint, ar WatchedUpdateRequest) (
 		return WatchedUpdateResponse{}, errors.New("failed to update watched entry")
 	}
 	originalThoughts := upwat.Thoughts
+	wasFinished := upwat.Status == FINISHED
 	if ar.Rating != 0 {
 		upwat.Rating = ar.Rating
 	}
@@ -236,6 +241,11 @@ func updateWatched(db *gorm.DB, userId uint, id uint, ar WatchedUpdateRequest) (
 	}
 	if ar.Status != "" {
 		addedActivity, _ = addActivity(db, userId, ActivityAddRequest{WatchedID: id, Type: STATUS_CHANGED, Data: string(ar.Status)})
+		if ar.Status == FINISHED {
+			finishWatchedView(db, userId, id, nil, upwat.Rating, time.Now())
+		} else if wasFinished {
+			startStatusWatchedView(db, userId, upwat)
+		}
 	}
 	if ar.Thoughts != "" {
 		addedActivity, _ = addActivity(db, userId, ActivityAddRequest{WatchedID: id, Type: THOUGHTS_CHANGED})
@@ -299,7 +309,7 @@ func download(url string, outf string, force bool) (err error) {
 	}
 
 	// Get the data
-	resp, err := http.Get(url)
+	resp, err := imagesHttp.Get(url)
 	if err != nil {
 		slog.Error("download: Failed to make request.", "outf", outf, "error", err)
 		return err
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type WatchedBulkRequest struct {
+	IDs        []uint        `json:"ids" binding:"required,min=1,max=1000"`
+	Status     WatchedStatus `json:"status" binding:"omitempty,oneof=FINISHED WATCHING PLANNED HOLD DROPPED"`
+	Rating     float64       `json:"rating" binding:"min=0,max=10"`
+	Pinned     *bool         `json:"pinned"`
+	AddTags    []uint        `json:"addTags"`
+	RemoveTags []uint        `json:"removeTags"`
+	// Remove the watched items (can't be combined with other changes).
+	Delete bool `json:"delete"`
+}
+
+type WatchedBulkResponse struct {
+	// Number of watched items changed.
+	Updated int `json:"updated"`
+	// Activity added for each item (the last activity when more than one change was made).
+	NewActivity []Activity `json:"newActivity"`
+}
+
+// Apply the same changes to many watched items, in one transaction
+// so nothing is changed if any item fails.
+func bulkUpdateWatched(db *gorm.DB, userId uint, br WatchedBulkRequest) (WatchedBulkResponse, error) {
+	hasUpdate := br.Status != "" || br.Rating != 0 || br.Pinned != nil
+	if br.Delete && (hasUpdate || len(br.AddTags) > 0 || len(br.RemoveTags) > 0) {
+		return WatchedBulkResponse{}, errors.New("delete can't be combined with other changes")
+	}
+	if !br.Delete && !hasUpdate && len(br.AddTags) == 0 && len(br.RemoveTags) == 0 {
+		return WatchedBulkResponse{}, errors.New("no changes requested")
+	}
+	slices.Sort(br.IDs)
+	ids := slices.Compact(br.IDs)
+	resp := WatchedBulkResponse{NewActivity: []Activity{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Check all items exist first, so we can give a useful error.
+		var count int64
+		if res := tx.Model(&Watched{}).Where("id IN ? AND user_id = ?", ids, userId).Count(&count); res.Error != nil {
+			slog.Error("bulkUpdateWatched: Failed to count watched items", "user_id", userId, "error", res.Error)
+			return errors.New("failed to get watched items")
+		}
+		if int(count) != len(ids) {
+			return errors.New("one or more watched entries do not exist")
+		}
+		for _, id := range ids {
+			var activity Activity
+			if br.Delete {
+				r, err := removeWatched(tx, userId, id)
+				if err != nil {
+					return err
+				}
+				activity = r.NewActivity
+			}
+			if hasUpdate {
+				r, err := updateWatched(tx, userId, id, WatchedUpdateRequest{Status: br.Status, Rating: br.Rating, Pinned: br.Pinned})
+				if err != nil {
+					return err
+				}
+				activity = r.NewActivity
+			}
+			for _, tagId := range br.AddTags {
+				if err := addWatchedToTag(tx, userId, tagId, id); err != nil {
+					return err
+				}
+			}
+			for _, tagId := range br.RemoveTags {
+				if err := rmWatchedFromTag(tx, userId, tagId, id); err != nil {
+					return err
+				}
+			}
+			if activity.ID != 0 {
+				resp.NewActivity = append(resp.NewActivity, activity)
+			}
+			resp.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("bulkUpdateWatched: Failed", "user_id", userId, "error", err)
+		return WatchedBulkResponse{}, err
+	}
+	return resp, nil
+}
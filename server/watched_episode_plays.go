@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A single play of a watched episode, so each (re)watch
+// keeps the date it was actually watched on.
+type WatchedEpisodePlay struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"-"`
+	UserID           uint      `json:"-" gorm:"not null;index"`
+	WatchedEpisodeID uint      `json:"watchedEpisodeId" gorm:"not null;index"`
+	WatchedAt        time.Time `json:"watchedAt" gorm:"not null;index"`
+}
+
+type WatchedEpisodePlayRequest struct {
+	// Defaults to now when adding a play.
+	WatchedAt *time.Time `json:"watchedAt"`
+}
+
+// Record a play of a watched episode.
+// When `dedupe` is true, the play won't be added if one already exists
+// at the same time (so syncs/imports can be ran more than once).
+func addEpisodePlay(db *gorm.DB, userId uint, watchedEpisodeId uint, at time.Time, dedupe bool) (WatchedEpisodePlay, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if dedupe {
+		existing := WatchedEpisodePlay{}
+		res := db.Where("watched_episode_id = ? AND watched_at = ?", watchedEpisodeId, at).Limit(1).Find(&existing)
+		if res.Error != nil {
+			slog.Error("addEpisodePlay: Failed to check for existing play", "watched_episode_id", watchedEpisodeId, "error", res.Error)
+			return WatchedEpisodePlay{}, errors.New("failed to add play")
+		}
+		if existing.ID != 0 {
+			return existing, nil
+		}
+	}
+	play := WatchedEpisodePlay{UserID: userId, WatchedEpisodeID: watchedEpisodeId, WatchedAt: at}
+	if res := db.Create(&play); res.Error != nil {
+		slog.Error("addEpisodePlay: Failed to add play", "watched_episode_id", watchedEpisodeId, "error", res.Error)
+		return WatchedEpisodePlay{}, errors.New("failed to add play")
+	}
+	return play, nil
+}
+
+// Add a play to one of the users watched episodes (eg, when rewatching it).
+func addWatchedEpisodePlay(db *gorm.DB, userId uint, watchedEpisodeId uint, ar WatchedEpisodePlayRequest) (WatchedEpisodePlay, error) {
+	var count int64
+	db.Model(&WatchedEpisode{}).Where("id = ? AND user_id = ?", watchedEpisodeId, userId).Count(&count)
+	if count == 0 {
+		return WatchedEpisodePlay{}, errors.New("watched episode does not exist")
+	}
+	var at time.Time
+	if ar.WatchedAt != nil {
+		at = *ar.WatchedAt
+	}
+	return addEpisodePlay(db, userId, watchedEpisodeId, at, false)
+}
+
+// Change the date of an episode play.
+func updateWatchedEpisodePlay(db *gorm.DB, userId uint, id uint, ar WatchedEpisodePlayRequest) (WatchedEpisodePlay, error) {
+	if ar.WatchedAt == nil || ar.WatchedAt.IsZero() {
+		return WatchedEpisodePlay{}, errors.New("watched date is required")
+	}
+	play := new(WatchedEpisodePlay)
+	if res := db.Where("id = ? AND user_id = ?", id, userId).Take(&play); res.Error != nil {
+		return WatchedEpisodePlay{}, errors.New("play does not exist")
+	}
+	play.WatchedAt = *ar.WatchedAt
+	if res := db.Save(&play); res.Error != nil {
+		slog.Error("updateWatchedEpisodePlay: Failed to update play", "id", id, "error", res.Error)
+		return WatchedEpisodePlay{}, errors.New("failed to update play")
+	}
+	return *play, nil
+}
+
+func deleteWatchedEpisodePlay(db *gorm.DB, userId uint, id uint) error {
+	res := db.Where("id = ? AND user_id = ?", id, userId).Delete(&WatchedEpisodePlay{})
+	if res.Error != nil {
+		slog.Error("deleteWatchedEpisodePlay: Failed to delete play", "id", id, "error", res.Error)
+		return errors.New("failed to delete play")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("play does not exist")
+	}
+	return nil
+}
+
+// Add a play for finished episodes that don't have one, dated when they were
+// added. Used once when plays are introduced, so existing history is kept.
+func backfillEpisodePlays(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO watched_episode_plays (created_at, user_id, watched_episode_id, watched_at)
+		SELECT ?, user_id, id, created_at FROM watched_episodes
+		WHERE status = ? AND deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM watched_episode_plays WHERE watched_episode_plays.watched_episode_id = watched_episodes.id)`,
+		time.Now(), FINISHED).Error
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,16 +25,21 @@ type WatchedEpisode struct {
 	EpisodeNumber int           `json:"episodeNumber" gorm:"uniqueIndex:we_watched_to_ens;not null"`
 	Status        WatchedStatus `json:"status"`
 	Rating        int8          `json:"rating"`
+	// Name of episode (from our episode cache).
+	Name  string               `json:"name,omitempty" gorm:"-"`
+	Plays []WatchedEpisodePlay `json:"plays,omitempty"`
 }
 
 type WatchedEpisodeAddRequest struct {
-	WatchedID       uint          `json:"watchedId"`
-	SeasonNumber    int           `json:"seasonNumber"`
-	EpisodeNumber   int           `json:"episodeNumber"`
-	Status          WatchedStatus `json:"status"`
-	Rating          int8          `json:"rating" binding:"max=10"`
-	addActivity     ActivityType  `json:"-"`
-	addActivityDate time.Time     `json:"-"`
+	WatchedID     uint          `json:"watchedId"`
+	SeasonNumber  int           `json:"seasonNumber"`
+	EpisodeNumber int           `json:"episodeNumber"`
+	Status        WatchedStatus `json:"status"`
+	Rating        int8          `json:"rating" binding:"max=10"`
+	// When the episode was watched, if finishing it (defaults to now).
+	WatchedAt       *time.Time   `json:"watchedAt"`
+	addActivity     ActivityType `json:"-"`
+	addActivityDate time.Time    `json:"-"`
 }
 
 type WatchedEpisodeAddResponse struct {
@@ -48,7 +54,7 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 	slog.Debug("Adding watched episode item", "userId", userId, "watchedID", ar.WatchedID, "season", ar.SeasonNumber, "episode", ar.EpisodeNumber)
 	// 1. Make sure watched item exists and it is the correct type (TV)
 	var w Watched
-	if resp := db.Where("id = ? AND user_id = ?", ar.WatchedID, userId).Preload("Content").Preload("WatchedEpisodes").Find(&w); resp.Error != nil {
+	if resp := db.Where("id = ? AND user_id = ?", ar.WatchedID, userId).Preload("Content").Preload("WatchedEpisodes.Plays").Find(&w); resp.Error != nil {
 		slog.Error("Failed when adding a watched episode", "error", "failed to get watched item from db")
 		return WatchedEpisodeAddResponse{}, errors.New("failed when retrieving watched item")
 	}
@@ -61,6 +67,7 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 	}
 	found := false
 	updated := false
+	finished := false
 	for i, we := range w.WatchedEpisodes {
 		if we.SeasonNumber == ar.SeasonNumber && we.EpisodeNumber == ar.EpisodeNumber {
 			slog.Debug("Existing watched episode item found, updating existing")
@@ -68,6 +75,7 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 			if ar.Status != "" && ar.Status != w.WatchedEpisodes[i].Status {
 				w.WatchedEpisodes[i].Status = ar.Status
 				updated = true
+				finished = ar.Status == FINISHED
 			}
 			if ar.Rating != 0 && ar.Rating != w.WatchedEpisodes[i].Rating {
 				w.WatchedEpisodes[i].Rating = ar.Rating
@@ -79,19 +87,50 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 	var addedActivity Activity
 	if !found {
 		slog.Debug("Existing watched episode not found, adding as new entry")
-		w.WatchedEpisodes = append(w.WatchedEpisodes, WatchedEpisode{
+		we := WatchedEpisode{
 			UserID:        userId,
 			WatchedID:     ar.WatchedID,
 			SeasonNumber:  ar.SeasonNumber,
 			EpisodeNumber: ar.EpisodeNumber,
-			Status:        ar.Status,
-			Rating:        ar.Rating,
-		})
+		}
+		// Restore the episode if it was removed, so its past plays come back with it.
+		if resp := db.Unscoped().Preload("Plays").Where("watched_id = ? AND season_number = ? AND episode_number = ? AND deleted_at IS NOT NULL", ar.WatchedID, ar.SeasonNumber, ar.EpisodeNumber).Find(&we); resp.Error != nil {
+			slog.Error("Failed when adding a watched episode", "error", "failed to get removed watched episode from db")
+			return WatchedEpisodeAddResponse{}, errors.New("failed when retrieving watched episode")
+		}
+		if we.ID != 0 {
+			if resp := db.Unscoped().Model(&we).Update("deleted_at", nil); resp.Error != nil {
+				slog.Error("Failed to restore removed watched episode", "error", resp.Error)
+				return WatchedEpisodeAddResponse{}, errors.New("failed to save")
+			}
+			we.DeletedAt = gorm.DeletedAt{}
+		}
+		we.Status = ar.Status
+		we.Rating = ar.Rating
+		w.WatchedEpisodes = append(w.WatchedEpisodes, we)
+		finished = ar.Status == FINISHED
 	}
-	if resp := db.Save(&w.WatchedEpisodes); resp.Error != nil {
+	if resp := db.Omit("Plays").Save(&w.WatchedEpisodes); resp.Error != nil {
 		slog.Debug("Failed to save watched episode item in db", "error", resp.Error)
 		return WatchedEpisodeAddResponse{}, errors.New("failed to save")
 	}
+	watchedAt := ar.addActivityDate
+	if ar.WatchedAt != nil {
+		watchedAt = *ar.WatchedAt
+	}
+	// Record a play when the episode is finished. Imports and syncs pass the
+	// date it was watched, which is recorded as another play if it's new to us.
+	if finished || (ar.Status == FINISHED && !watchedAt.IsZero()) {
+		for i, we := range w.WatchedEpisodes {
+			if we.SeasonNumber == ar.SeasonNumber && we.EpisodeNumber == ar.EpisodeNumber {
+				play, err := addEpisodePlay(db, userId, we.ID, watchedAt, !finished)
+				if err == nil && !slices.ContainsFunc(we.Plays, func(p WatchedEpisodePlay) bool { return p.ID == play.ID }) {
+					w.WatchedEpisodes[i].Plays = append(w.WatchedEpisodes[i].Plays, play)
+				}
+				break
+			}
+		}
+	}
 	// Add activity
 	if found {
 		// Only add change activity if we actually updated a value
@@ -112,8 +151,8 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 		if ar.addActivity != "" {
 			act.Type = ar.addActivity
 		}
-		if !ar.addActivityDate.IsZero() {
-			act.CustomDate = &ar.addActivityDate
+		if !watchedAt.IsZero() {
+			act.CustomDate = &watchedAt
 		}
 		addedActivity, _ = addActivity(db, userId, act)
 	}
@@ -132,7 +171,8 @@ func addWatchedEpisodes(db *gorm.DB, userId uint, ar WatchedEpisodeAddRequest) (
 func rmWatchedEpisode(db *gorm.DB, userId uint, id uint) (Activity, error) {
 	slog.Debug("rmWatchedSeason called", "user_id", userId, "id", id)
 	var watchedEpisode WatchedEpisode
-	resp := db.Clauses(clause.Returning{}).Model(&WatchedEpisode{}).Unscoped().Where("id = ? AND user_id = ?", id, userId).Delete(&watchedEpisode)
+	// Soft deleted so its plays are kept (they come back if the episode is added again).
+	resp := db.Clauses(clause.Returning{}).Model(&WatchedEpisode{}).Where("id = ? AND user_id = ?", id, userId).Delete(&watchedEpisode)
 	if resp.Error != nil {
 		slog.Error("Failed when removing a watched episode", "error", resp.Error)
 		return Activity{}, errors.New("failed when removing watched episode")
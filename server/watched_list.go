@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Query parameters for filtering, sorting and paginating a users watched list.
+type WatchedListQuery struct {
+	Status    []WatchedStatus `form:"status" binding:"dive,oneof=FINISHED WATCHING PLANNED HOLD DROPPED"`
+	Type      string          `form:"type" binding:"omitempty,oneof=movie tv game"`
+	Tag       []uint          `form:"tag"`
+	MinRating *float64        `form:"minRating" binding:"omitempty,min=0,max=10"`
+	MaxRating *float64        `form:"maxRating" binding:"omitempty,min=0,max=10"`
+	// Date range for when items were added/watched.
+	From *time.Time `form:"from" time_format:"2006-01-02" time_location:"Local"`
+	To   *time.Time `form:"to" time_format:"2006-01-02" time_location:"Local"`
+	// Search content/game titles.
+	Query string `form:"q" binding:"max=200"`
+	Sort  string `form:"sort" binding:"omitempty,oneof=added updated rating title release"`
+	Order string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	// Cursor from the previous page (`nextCursor`).
+	Cursor string `form:"cursor"`
+	// Only include content, game and tags (no activity, seasons, episodes or views).
+	Lite bool `form:"lite"`
+}
+
+type WatchedListResponse struct {
+	Results []Watched `json:"results"`
+	// Pass as `cursor` to get the next page, empty when there are no more results.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type watchedListCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+type watchedListSort struct {
+	expr    string
+	numeric bool
+}
+
+// Sortable columns. Values are coalesced, so they are compared as stored
+// (for dates) and items without a value sort last when descending.
+var watchedListSorts = map[string]watchedListSort{
+	"added":   {expr: "COALESCE(watcheds.created_at, '')"},
+	"updated": {expr: "COALESCE(watcheds.updated_at, '')"},
+	"rating":  {expr: "COALESCE(watcheds.rating, 0)", numeric: true},
+	"title":   {expr: "LOWER(COALESCE(contents.title, games.name, ''))"},
+	"release": {expr: "COALESCE(contents.release_date, games.release_date, '')"},
+}
+
+const watchedListDefaultLimit = 50
+
+var errInvalidWatchedListCursor = errors.New("invalid cursor")
+
+// Escapes LIKE wildcards, so searches match them literally (use with ESCAPE '\').
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Preload everything a watched item has, or only what
+// list views need when `lite`.
+func preloadWatched(q *gorm.DB, lite bool) *gorm.DB {
+	q = q.Preload("Content").
+		Preload("Game").
+		Preload("Game.Poster").
+		Preload("Tags", notDeleted)
+	if lite {
+		return q
+	}
+	return q.Preload("Activity", notDeleted).
+		Preload("WatchedSeasons", notDeleted).
+		Preload("WatchedEpisodes", notDeleted).
+		Preload("WatchedEpisodes.Plays", func(db *gorm.DB) *gorm.DB {
+			return db.Order("watched_at")
+		}).
+		Preload("Views", notDeleted)
+}
+
+// Exclude soft deleted rows from a preload. Needed when preloading for an
+// Unscoped query (the trash), since it carries over to preloads too.
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where("deleted_at IS NULL")
+}
+
+// Get a page of a users watched list, filtered and sorted by `lq`.
+func getWatchedList(db *gorm.DB, userId uint, lq WatchedListQuery) (WatchedListResponse, error) {
+	sort, ok := watchedListSorts[lq.Sort]
+	if !ok {
+		sort = watchedListSorts["updated"]
+	}
+	desc := lq.Order != "asc"
+	limit := lq.Limit
+	if limit == 0 {
+		limit = watchedListDefaultLimit
+	}
+	q := db.Model(&Watched{}).
+		Joins("LEFT JOIN contents ON contents.id = watcheds.content_id").
+		Joins("LEFT JOIN games ON games.id = watcheds.game_id").
+		Where("watcheds.user_id = ?", userId)
+	if len(lq.Status) > 0 {
+		q = q.Where("watcheds.status IN ?", lq.Status)
+	}
+	switch lq.Type {
+	case "game":
+		q = q.Where("watcheds.game_id IS NOT NULL")
+	case string(MOVIE), string(SHOW):
+		q = q.Where("contents.type = ?", lq.Type)
+	}
+	if len(lq.Tag) > 0 {
+		q = q.Where("watcheds.id IN (SELECT watched_id FROM watched_tags WHERE tag_id IN ?)", lq.Tag)
+	}
+	if lq.MinRating != nil {
+		q = q.Where("watcheds.rating >= ?", *lq.MinRating)
+	}
+	if lq.MaxRating != nil {
+		q = q.Where("watcheds.rating <= ?", *lq.MaxRating)
+	}
+	if lq.From != nil {
+		q = q.Where("watcheds.created_at >= ?", *lq.From)
+	}
+	if lq.To != nil {
+		// `to` is inclusive of the whole day.
+		q = q.Where("watcheds.created_at < ?", lq.To.AddDate(0, 0, 1))
+	}
+	if lq.Query != "" {
+		search := "%" + likeEscaper.Replace(lq.Query) + "%"
+		q = q.Where(`contents.title LIKE ? ESCAPE '\' OR games.name LIKE ? ESCAPE '\'`, search, search)
+	}
+	if lq.Cursor != "" {
+		cursor, err := decodeWatchedListCursor(lq.Cursor)
+		if err != nil {
+			return WatchedListResponse{}, err
+		}
+		var v interface{} = cursor.Value
+		if sort.numeric {
+			f, err := strconv.ParseFloat(cursor.Value, 64)
+			if err != nil {
+				return WatchedListResponse{}, errInvalidWatchedListCursor
+			}
+			v = f
+		}
+		cmp := ">"
+		if desc {
+			cmp = "<"
+		}
+		q = q.Where("("+sort.expr+" "+cmp+" ? OR ("+sort.expr+" = ? AND watcheds.id "+cmp+" ?))", v, v, cursor.ID)
+	}
+	order := " ASC"
+	if desc {
+		order = " DESC"
+	}
+	// Get one more than needed, to know if there is another page.
+	rows := []struct {
+		ID        uint
+		SortValue string
+	}{}
+	res := q.Select("watcheds.id, " + sort.expr + " AS sort_value").
+		Order(sort.expr + order).
+		Order("watcheds.id" + order).
+		Limit(limit + 1).
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("getWatchedList: Failed to query watched list", "user_id", userId, "error", res.Error)
+		return WatchedListResponse{}, errors.New("failed to get watched list")
+	}
+	resp := WatchedListResponse{Results: []Watched{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		resp.NextCursor = encodeWatchedListCursor(watchedListCursor{Value: last.SortValue, ID: last.ID})
+	}
+	if len(rows) == 0 {
+		return resp, nil
+	}
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	watched := []Watched{}
+	if res := preloadWatched(db.Model(&Watched{}), lq.Lite).Where("id IN ?", ids).Find(&watched); res.Error != nil {
+		slog.Error("getWatchedList: Failed to get watched items", "user_id", userId, "error", res.Error)
+		return WatchedListResponse{}, errors.New("failed to get watched list")
+	}
+	// Put back in the order we queried them in.
+	pos := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		pos[id] = i
+	}
+	slices.SortFunc(watched, func(a, b Watched) int {
+		return pos[a.ID] - pos[b.ID]
+	})
+	if !lq.Lite {
+		addWatchedEpisodeNames(db, watched)
+	}
+	resp.Results = watched
+	return resp, nil
+}
+
+func encodeWatchedListCursor(c watchedListCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeWatchedListCursor(s string) (watchedListCursor, error) {
+	var c watchedListCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errInvalidWatchedListCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return c, errInvalidWatchedListCursor
+	}
+	return c, nil
+}
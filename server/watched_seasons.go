@@ -86,6 +86,10 @@ func addWatchedSeason(db *gorm.DB, userId uint, ar WatchedSeasonAddRequest) (Wat
 		slog.Debug("Failed to save watched season item in db", "error", resp.Error)
 		return WatchedSeasonAddResponse{}, errors.New("failed to save")
 	}
+	if ar.Status == FINISHED && (updated || !found) {
+		seasonNum := ar.SeasonNumber
+		finishWatchedView(db, userId, w.ID, &seasonNum, float64(ar.Rating), time.Now())
+	}
 	// Add activity
 	if found {
 		// Only add change activity if we actually updated a value
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const trashDefaultRetentionDays = 30
+
+type WatchedRestoreResponse struct {
+	Watched     Watched  `json:"watched"`
+	NewActivity Activity `json:"newActivity"`
+}
+
+func getTrashRetentionDays() int {
+	if Config.TRASH_RETENTION_DAYS > 0 {
+		return Config.TRASH_RETENTION_DAYS
+	}
+	return trashDefaultRetentionDays
+}
+
+// Get users removed (soft deleted) watched items, with everything they had
+// when removed, most recently removed first.
+func getWatchedTrash(db *gorm.DB, userId uint) ([]Watched, error) {
+	watched := []Watched{}
+	res := preloadWatched(db.Model(&Watched{}).Unscoped(), false).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userId).
+		Order("deleted_at DESC").
+		Find(&watched)
+	if res.Error != nil {
+		slog.Error("getWatchedTrash: Failed to get removed watched items", "user_id", userId, "error", res.Error)
+		return []Watched{}, errors.New("failed to get trash")
+	}
+	addWatchedEpisodeNames(db, watched)
+	return watched, nil
+}
+
+// Restore a removed watched item. Its seasons, episodes, views and activity
+// are left untouched when it's removed, so they come back with it.
+func restoreWatched(db *gorm.DB, userId uint, id uint) (WatchedRestoreResponse, error) {
+	res := db.Model(&Watched{}).Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		slog.Error("restoreWatched: Failed to restore watched item", "id", id, "error", res.Error)
+		return WatchedRestoreResponse{}, errors.New("failed to restore watched entry")
+	}
+	if res.RowsAffected == 0 {
+		return WatchedRestoreResponse{}, errors.New("watched entry is not in trash")
+	}
+	watched := Watched{}
+	if res := preloadWatched(db.Model(&Watched{}), false).Where("id = ?", id).Take(&watched); res.Error != nil {
+		slog.Error("restoreWatched: Failed to get restored watched item", "id", id, "error", res.Error)
+		return WatchedRestoreResponse{}, errors.New("restored, but failed to get watched entry")
+	}
+	addWatchedEpisodeNames(db, []Watched{watched})
+	activity, _ := addActivity(db, userId, ActivityAddRequest{WatchedID: id, Type: RESTORED_WATCHED})
+	watched.Activity = append(watched.Activity, activity)
+	return WatchedRestoreResponse{Watched: watched, NewActivity: activity}, nil
+}
+
+// Permanently delete a removed watched item from users trash.
+func deleteWatchedFromTrash(db *gorm.DB, userId uint, id uint) error {
+	var count int64
+	db.Model(&Watched{}).Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).Count(&count)
+	if count == 0 {
+		return errors.New("watched entry is not in trash")
+	}
+	if err := purgeWatched(db, []uint{id}); err != nil {
+		slog.Error("deleteWatchedFromTrash: Failed to delete watched item", "id", id, "error", err)
+		return errors.New("failed to delete watched entry")
+	}
+	return nil
+}
+
+// Purge watched items that have been in the trash longer than the retention period.
+func purgeWatchedTrash(db *gorm.DB) {
+	before := time.Now().AddDate(0, 0, -getTrashRetentionDays())
+	ids := []uint{}
+	res := db.Model(&Watched{}).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids)
+	if res.Error != nil {
+		slog.Error("purgeWatchedTrash: Failed to get expired watched items", "error", res.Error)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := purgeWatched(db, ids); err != nil {
+		slog.Error("purgeWatchedTrash: Failed to purge watched items", "error", err)
+		return
+	}
+	slog.Info("purgeWatchedTrash: Purged watched items from trash", "count", len(ids))
+}
+
+// Hard delete watched items and everything linked to them.
+func purgeWatched(db *gorm.DB, ids []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("watched_episode_id IN (?)", tx.Model(&WatchedEpisode{}).Unscoped().Select("id").Where("watched_id IN ?", ids)).Delete(&WatchedEpisodePlay{}).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{&WatchedEpisode{}, &WatchedSeason{}, &WatchedView{}, &Activity{}} {
+			if err := tx.Unscoped().Where("watched_id IN ?", ids).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM watched_tags WHERE watched_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&Watched{}).Error
+	})
+}
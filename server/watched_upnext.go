@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UpNextItem struct {
+	WatchedID uint     `json:"watchedId"`
+	Content   *Content `json:"content"`
+	// The next aired episode the user hasn't watched.
+	NextEpisode Episode `json:"nextEpisode"`
+	// Number of aired episodes after (and including) the next episode.
+	RemainingEpisodes int `json:"remainingEpisodes"`
+	// If the show was finished and new episodes have since aired.
+	New bool `json:"new"`
+	// When the user last watched something for this show.
+	LastWatchedAt time.Time `json:"lastWatchedAt"`
+}
+
+// Activity that counts as watching something (not ratings, thoughts, etc).
+var watchActivityTypes = []ActivityType{
+	ADDED_WATCHED, STATUS_CHANGED, STATUS_CHANGED_AUTO,
+	IMPORTED_WATCHED, IMPORTED_WATCHED_JF, IMPORTED_WATCHED_PLEX,
+	IMPORTED_ADDED_WATCHED, IMPORTED_ADDED_WATCHED_JF, IMPORTED_ADDED_WATCHED_PLEX,
+	SEASON_ADDED, SEASON_ADDED_AUTO, SEASON_ADDED_JF, SEASON_ADDED_PLEX, SEASON_STATUS_CHANGED, SEASON_STATUS_CHANGED_AUTO,
+	EPISODE_ADDED, EPISODE_ADDED_JF, EPISODE_ADDED_PLEX, EPISODE_STATUS_CHANGED,
+	VIEW_STARTED, VIEW_FINISHED,
+}
+
+// When a user last watched something for a show, and when they last finished it.
+type watchDates struct {
+	LastWatched time.Time
+	Finished    time.Time
+}
+
+// Episode position in a show, used for ordering.
+type episodePos struct {
+	Season  int
+	Episode int
+}
+
+func (p episodePos) after(o episodePos) bool {
+	return p.Season > o.Season || (p.Season == o.Season && p.Episode > o.Episode)
+}
+
+// Get next episode to watch for all shows being watched.
+// includeFinished - Also include finished shows that have new episodes.
+func getUpNext(db *gorm.DB, userId uint, includeFinished bool) ([]UpNextItem, error) {
+	statuses := []WatchedStatus{WATCHING}
+	if includeFinished {
+		statuses = append(statuses, FINISHED)
+	}
+	watched := []Watched{}
+	res := db.Model(&Watched{}).
+		Preload("Content").
+		Preload("WatchedSeasons").
+		Preload("WatchedEpisodes").
+		Joins("JOIN contents ON contents.id = watcheds.content_id").
+		Where("watcheds.user_id = ? AND watcheds.status IN ? AND contents.type = ?", userId, statuses, SHOW).
+		Find(&watched)
+	if res.Error != nil {
+		slog.Error("getUpNext: Failed to get watched shows", "user_id", userId, "error", res.Error)
+		return []UpNextItem{}, errors.New("failed to get watched shows")
+	}
+	if len(watched) == 0 {
+		return []UpNextItem{}, nil
+	}
+
+	contentIds := make([]int, len(watched))
+	for i, w := range watched {
+		contentIds[i] = w.Content.ID
+	}
+	episodes, err := getUpNextEpisodes(db, watched, contentIds)
+	if err != nil {
+		return []UpNextItem{}, errors.New("failed to get episodes")
+	}
+	dates, err := getWatchDates(db, watched)
+	if err != nil {
+		return []UpNextItem{}, errors.New("failed to get watch dates")
+	}
+
+	upNext := []UpNextItem{}
+	for _, w := range watched {
+		item, ok := getUpNextForWatched(w, episodes[w.Content.ID], dates[w.ID])
+		if ok {
+			upNext = append(upNext, item)
+		}
+	}
+	// Most recently watched first.
+	sort.SliceStable(upNext, func(i, j int) bool {
+		return upNext[i].LastWatchedAt.After(upNext[j].LastWatchedAt)
+	})
+	return upNext, nil
+}
+
+// Get aired episodes (excluding specials) for shows, in order, keyed by content id.
+// Shows we don't have all seasons cached for are cached in the background,
+// so they will be missing episodes until that is done.
+func getUpNextEpisodes(db *gorm.DB, watched []Watched, contentIds []int) (map[int][]Episode, error) {
+	contents := make([]Content, len(watched))
+	for i, w := range watched {
+		contents[i] = *w.Content
+	}
+	missing, err := getShowsMissingSeasons(db, contents)
+	if err != nil {
+		return nil, err
+	}
+	queueCacheShowSeasons(db, missing)
+	episodes := []Episode{}
+	res := db.Where("content_id IN ? AND season_number > 0 AND air_date <= ?", contentIds, time.Now()).
+		Order("season_number, episode_number").
+		Find(&episodes)
+	if res.Error != nil {
+		slog.Error("getUpNextEpisodes: Failed to get episodes", "error", res.Error)
+		return nil, res.Error
+	}
+	byContent := map[int][]Episode{}
+	for _, e := range episodes {
+		byContent[e.ContentID] = append(byContent[e.ContentID], e)
+	}
+	return byContent, nil
+}
+
+// Get when each watched show was last watched and finished, from episode plays
+// and activity. Ratings, thoughts, etc don't count as watching.
+func getWatchDates(db *gorm.DB, watched []Watched) (map[uint]watchDates, error) {
+	dates := map[uint]watchDates{}
+	ids := make([]uint, len(watched))
+	for i, w := range watched {
+		ids[i] = w.ID
+	}
+	plays := []struct {
+		WatchedID uint
+		WatchedAt time.Time
+	}{}
+	res := db.Model(&WatchedEpisodePlay{}).
+		Select("watched_episodes.watched_id, watched_episode_plays.watched_at").
+		Joins("JOIN watched_episodes ON watched_episodes.id = watched_episode_plays.watched_episode_id AND watched_episodes.deleted_at IS NULL").
+		Where("watched_episodes.watched_id IN ?", ids).
+		Scan(&plays)
+	if res.Error != nil {
+		slog.Error("getWatchDates: Failed to get episode plays", "error", res.Error)
+		return dates, res.Error
+	}
+	activity := []Activity{}
+	res = db.Select("watched_id", "type", "data", "created_at", "custom_date").Where("watched_id IN ? AND type IN ?", ids, watchActivityTypes).Find(&activity)
+	if res.Error != nil {
+		slog.Error("getWatchDates: Failed to get activity", "error", res.Error)
+		return dates, res.Error
+	}
+	for _, p := range plays {
+		d := dates[p.WatchedID]
+		if p.WatchedAt.After(d.LastWatched) {
+			d.LastWatched = p.WatchedAt
+		}
+		dates[p.WatchedID] = d
+	}
+	for _, a := range activity {
+		date := a.CreatedAt
+		if a.CustomDate != nil {
+			date = *a.CustomDate
+		}
+		d := dates[a.WatchedID]
+		if date.After(d.LastWatched) {
+			d.LastWatched = date
+		}
+		if isFinishedActivity(a) && date.After(d.Finished) {
+			d.Finished = date
+		}
+		dates[a.WatchedID] = d
+	}
+	// Shows without any activity (shouldn't happen), were at least watched when added.
+	for _, w := range watched {
+		d := dates[w.ID]
+		if d.LastWatched.IsZero() {
+			d.LastWatched = w.CreatedAt
+		}
+		if d.Finished.IsZero() {
+			d.Finished = d.LastWatched
+		}
+		dates[w.ID] = d
+	}
+	return dates, nil
+}
+
+// Work out the next episode for a watched show from its aired episodes (in order).
+// The next episode is the first unwatched one after the furthest the user has got to,
+// so skipped episodes earlier in the show don't hold it back.
+func getUpNextForWatched(w Watched, episodes []Episode, dates watchDates) (UpNextItem, bool) {
+	if len(episodes) == 0 {
+		return UpNextItem{}, false
+	}
+	watchedEps := map[episodePos]bool{}
+	var furthest *episodePos
+	for _, we := range w.WatchedEpisodes {
+		if we.Status != FINISHED && we.Status != DROPPED {
+			continue
+		}
+		p := episodePos{we.SeasonNumber, we.EpisodeNumber}
+		watchedEps[p] = true
+		if furthest == nil || p.after(*furthest) {
+			furthest = &p
+		}
+	}
+	finishedSeasons := map[int]bool{}
+	for _, ws := range w.WatchedSeasons {
+		if ws.Status == FINISHED || ws.Status == DROPPED {
+			finishedSeasons[ws.SeasonNumber] = true
+			// Furthest is at least the end of a finished season.
+			p := episodePos{ws.SeasonNumber, math.MaxInt}
+			if furthest == nil || p.after(*furthest) {
+				furthest = &p
+			}
+		}
+	}
+
+	next := -1
+	if furthest == nil && w.Status == FINISHED {
+		// No progress tracked, so anything aired after it was finished is new.
+		for i, e := range episodes {
+			if e.AirDate != nil && e.AirDate.After(dates.Finished) {
+				next = i
+				break
+			}
+		}
+	} else {
+		for i, e := range episodes {
+			p := episodePos{e.SeasonNumber, e.EpisodeNumber}
+			if watchedEps[p] || finishedSeasons[e.SeasonNumber] {
+				continue
+			}
+			if furthest == nil || p.after(*furthest) {
+				next = i
+				break
+			}
+		}
+	}
+	if next == -1 {
+		return UpNextItem{}, false
+	}
+	return UpNextItem{
+		WatchedID:         w.ID,
+		Content:           w.Content,
+		NextEpisode:       episodes[next],
+		RemainingEpisodes: len(episodes) - next,
+		New:               w.Status == FINISHED,
+		LastWatchedAt:     dates.LastWatched,
+	}, true
+}
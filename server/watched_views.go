@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A single viewing of a watched list item.
+// Lets users rewatch content (a movie, a whole show or a single
+// season of a show), keeping the dates, rating and notes of each view.
+type WatchedView struct {
+	GormModel
+	UserID    uint `json:"-" gorm:"not null;index"`
+	WatchedID uint `json:"watchedId" gorm:"not null;index"`
+	// Season this view is for, nil if for the whole movie/show.
+	SeasonNumber *int       `json:"seasonNumber,omitempty"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	// Nil while the view is in progress.
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Rating for this view (out of 10.0, like Watched.Rating).
+	Rating float64 `json:"rating" gorm:"type:numeric(2,1)"`
+	Notes  string  `json:"notes"`
+}
+
+type WatchedViewStartRequest struct {
+	WatchedID    uint       `json:"watchedId" binding:"required"`
+	SeasonNumber *int       `json:"seasonNumber" binding:"omitempty,min=0"`
+	StartedAt    *time.Time `json:"startedAt"`
+}
+
+type WatchedViewUpdateRequest struct {
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+	Rating     *float64   `json:"rating" binding:"omitempty,min=0,max=10"`
+	Notes      *string    `json:"notes" binding:"omitempty,max=5000"`
+}
+
+type WatchedViewResponse struct {
+	View          WatchedView `json:"view"`
+	AddedActivity Activity    `json:"addedActivity"`
+}
+
+func viewSeasonQuery(q *gorm.DB, seasonNum *int) *gorm.DB {
+	if seasonNum == nil {
+		return q.Where("season_number IS NULL")
+	}
+	return q.Where("season_number = ?", *seasonNum)
+}
+
+// Start a new view (rewatch) of a watched list item.
+func startWatchedView(db *gorm.DB, userId uint, ar WatchedViewStartRequest) (WatchedViewResponse, error) {
+	w, err := getWatchedItemById(db, userId, ar.WatchedID)
+	if err != nil || w.ID == 0 {
+		return WatchedViewResponse{}, errors.New("watched item does not exist")
+	}
+	if ar.SeasonNumber != nil && (w.Content == nil || w.Content.Type != SHOW) {
+		return WatchedViewResponse{}, errors.New("season views can only be added for shows")
+	}
+	var inProgress int64
+	viewSeasonQuery(db.Model(&WatchedView{}).Where("watched_id = ? AND finished_at IS NULL", w.ID), ar.SeasonNumber).Count(&inProgress)
+	if inProgress > 0 {
+		return WatchedViewResponse{}, errors.New("a rewatch is already in progress")
+	}
+	if ar.SeasonNumber == nil && w.Status == FINISHED {
+		addFirstWatchedView(db, userId, w)
+	}
+	startedAt := time.Now()
+	if ar.StartedAt != nil {
+		startedAt = *ar.StartedAt
+	}
+	view := WatchedView{UserID: userId, WatchedID: w.ID, SeasonNumber: ar.SeasonNumber, StartedAt: &startedAt}
+	if res := db.Create(&view); res.Error != nil {
+		slog.Error("startWatchedView: Failed to create view", "watched_id", w.ID, "error", res.Error)
+		return WatchedViewResponse{}, errors.New("failed to start rewatch")
+	}
+	actData, _ := json.Marshal(map[string]interface{}{"viewId": view.ID, "season": ar.SeasonNumber})
+	activity, _ := addActivity(db, userId, ActivityAddRequest{WatchedID: w.ID, Type: VIEW_STARTED, Data: string(actData), CustomDate: &startedAt})
+	return WatchedViewResponse{View: view, AddedActivity: activity}, nil
+}
+
+// Content finished before views existed won't have one for the
+// first watch, add it so it's counted along with a rewatch.
+func addFirstWatchedView(db *gorm.DB, userId uint, w Watched) {
+	var views int64
+	db.Model(&WatchedView{}).Where("watched_id = ? AND season_number IS NULL", w.ID).Count(&views)
+	if views > 0 {
+		return
+	}
+	finishedAt := getLastFinishedDate(db, w)
+	view := WatchedView{UserID: userId, WatchedID: w.ID, FinishedAt: &finishedAt, Rating: w.Rating}
+	if !w.CreatedAt.After(finishedAt) {
+		view.StartedAt = &w.CreatedAt
+	}
+	if res := db.Create(&view); res.Error != nil {
+		slog.Error("addFirstWatchedView: Failed to add first view", "watched_id", w.ID, "error", res.Error)
+	}
+}
+
+// Get when a watched item was last finished, from its activity.
+// Falls back to when it was added if it has no finished activity.
+func getLastFinishedDate(db *gorm.DB, w Watched) time.Time {
+	finishedAt := w.CreatedAt
+	activity := []Activity{}
+	if res := db.Where("watched_id = ? AND type IN ?", w.ID, finishedActivityTypes).Find(&activity); res.Error != nil {
+		slog.Error("getLastFinishedDate: Failed to get activity", "watched_id", w.ID, "error", res.Error)
+		return finishedAt
+	}
+	found := false
+	for _, a := range activity {
+		if !isFinishedActivity(a) {
+			continue
+		}
+		date := a.CreatedAt
+		if a.CustomDate != nil {
+			date = *a.CustomDate
+		}
+		if !found || date.After(finishedAt) {
+			finishedAt = date
+			found = true
+		}
+	}
+	return finishedAt
+}
+
+// Start a view when a finished item is being watched again (its status
+// changed from finished), so it's counted when it's finished again.
+func startStatusWatchedView(db *gorm.DB, userId uint, w Watched) {
+	addFirstWatchedView(db, userId, w)
+	var inProgress int64
+	db.Model(&WatchedView{}).Where("watched_id = ? AND season_number IS NULL AND finished_at IS NULL", w.ID).Count(&inProgress)
+	if inProgress > 0 {
+		return
+	}
+	startedAt := time.Now()
+	if res := db.Create(&WatchedView{UserID: userId, WatchedID: w.ID, StartedAt: &startedAt}); res.Error != nil {
+		slog.Error("startStatusWatchedView: Failed to start view", "watched_id", w.ID, "error", res.Error)
+	}
+}
+
+func updateWatchedView(db *gorm.DB, userId uint, id uint, ar WatchedViewUpdateRequest) (WatchedViewResponse, error) {
+	view := new(WatchedView)
+	if res := db.Where("id = ? AND user_id = ?", id, userId).Take(&view); res.Error != nil {
+		return WatchedViewResponse{}, errors.New("view does not exist")
+	}
+	wasFinished := view.FinishedAt != nil
+	if ar.StartedAt != nil {
+		view.StartedAt = ar.StartedAt
+	}
+	if ar.FinishedAt != nil {
+		view.FinishedAt = ar.FinishedAt
+	}
+	if ar.Rating != nil {
+		view.Rating = *ar.Rating
+	}
+	if ar.Notes != nil {
+		view.Notes = *ar.Notes
+	}
+	if view.StartedAt != nil && view.FinishedAt != nil && view.FinishedAt.Before(*view.StartedAt) {
+		return WatchedViewResponse{}, errors.New("finish date can't be before start date")
+	}
+	if res := db.Save(&view); res.Error != nil {
+		slog.Error("updateWatchedView: Failed to update view", "id", id, "error", res.Error)
+		return WatchedViewResponse{}, errors.New("failed to update view")
+	}
+	var activity Activity
+	if !wasFinished && view.FinishedAt != nil {
+		actData, _ := json.Marshal(map[string]interface{}{"viewId": view.ID, "season": view.SeasonNumber, "rating": view.Rating})
+		activity, _ = addActivity(db, userId, ActivityAddRequest{WatchedID: view.WatchedID, Type: VIEW_FINISHED, Data: string(actData), CustomDate: view.FinishedAt})
+	}
+	return WatchedViewResponse{View: *view, AddedActivity: activity}, nil
+}
+
+func deleteWatchedView(db *gorm.DB, userId uint, id uint) (WatchedViewResponse, error) {
+	view := new(WatchedView)
+	if res := db.Where("id = ? AND user_id = ?", id, userId).Take(&view); res.Error != nil {
+		return WatchedViewResponse{}, errors.New("view does not exist")
+	}
+	if res := db.Delete(&view); res.Error != nil {
+		slog.Error("deleteWatchedView: Failed to delete view", "id", id, "error", res.Error)
+		return WatchedViewResponse{}, errors.New("failed to delete view")
+	}
+	actData, _ := json.Marshal(map[string]interface{}{"viewId": view.ID, "season": view.SeasonNumber})
+	activity, _ := addActivity(db, userId, ActivityAddRequest{WatchedID: view.WatchedID, Type: VIEW_REMOVED, Data: string(actData)})
+	return WatchedViewResponse{View: *view, AddedActivity: activity}, nil
+}
+
+// Mark views finished when content (or a season) is finished.
+// Finishes the in progress view if there is one, otherwise when the
+// whole item is finished for the first time, its first view is added.
+func finishWatchedView(db *gorm.DB, userId uint, watchedId uint, seasonNum *int, rating float64, at time.Time) {
+	res := viewSeasonQuery(db.Model(&WatchedView{}).Where("watched_id = ? AND user_id = ? AND finished_at IS NULL", watchedId, userId), seasonNum).
+		Update("finished_at", at)
+	if res.Error != nil {
+		slog.Error("finishWatchedView: Failed to finish view", "watched_id", watchedId, "error", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 || seasonNum != nil {
+		return
+	}
+	var views int64
+	db.Model(&WatchedView{}).Where("watched_id = ? AND season_number IS NULL", watchedId).Count(&views)
+	if views > 0 {
+		return
+	}
+	var createdAt time.Time
+	db.Model(&Watched{}).Select("created_at").Where("id = ?", watchedId).Scan(&createdAt)
+	view := WatchedView{UserID: userId, WatchedID: watchedId, FinishedAt: &at, Rating: rating}
+	if !createdAt.IsZero() && !createdAt.After(at) {
+		view.StartedAt = &createdAt
+	}
+	if res := db.Create(&view); res.Error != nil {
+		slog.Error("finishWatchedView: Failed to add first view", "watched_id", watchedId, "error", res.Error)
+	}
+}
+
+// Get number of finished whole views for each of a users watched items.
+func getWatchedViewCounts(db *gorm.DB, userId uint) map[uint]int {
+	counts := map[uint]int{}
+	rows := []struct {
+		WatchedID uint
+		Count     int
+	}{}
+	res := db.Model(&WatchedView{}).
+		Select("watched_id, COUNT(*) AS count").
+		Where("user_id = ? AND season_number IS NULL AND finished_at IS NOT NULL", userId).
+		Group("watched_id").
+		Scan(&rows)
+	if res.Error != nil {
+		slog.Error("getWatchedViewCounts: Failed to count views", "user_id", userId, "error", res.Error)
+		return counts
+	}
+	for _, r := range rows {
+		counts[r.WatchedID] = r.Count
+	}
+	return counts
+}
+
+// Get number of finished season views and their runtime (from cached episodes).
+func getSeasonViewStats(db *gorm.DB, userId uint) (int32, uint32) {
+	var views int64
+	db.Model(&WatchedView{}).
+		Joins("JOIN watcheds ON watcheds.id = watched_views.watched_id AND watcheds.deleted_at IS NULL").
+		Where("watched_views.user_id = ? AND watched_views.season_number IS NOT NULL AND watched_views.finished_at IS NOT NULL", userId).
+		Count(&views)
+	var runtime uint32
+	res := db.Model(&WatchedView{}).
+		Select("COALESCE(SUM(episodes.runtime), 0)").
+		Joins("JOIN watcheds ON watcheds.id = watched_views.watched_id AND watcheds.deleted_at IS NULL").
+		Joins("JOIN episodes ON episodes.content_id = watcheds.content_id AND episodes.season_number = watched_views.season_number").
+		Where("watched_views.user_id = ? AND watched_views.season_number IS NOT NULL AND watched_views.finished_at IS NOT NULL", userId).
+		Scan(&runtime)
+	if res.Error != nil {
+		slog.Error("getSeasonViewStats: Failed to get season view runtime", "user_id", userId, "error", res.Error)
+	}
+	return int32(views), runtime
+}